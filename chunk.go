@@ -0,0 +1,89 @@
+package stringwrap
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+// Chunker splits messages into chunks of at most a number of bytes, such
+// as for IRC lines, syslog messages or chat APIs with a size limit.
+//
+// Chunks never cut a UTF-8 sequence, grapheme cluster or ANSI escape
+// sequence, and break at word boundaries where possible. Words longer
+// than a chunk are split between grapheme clusters without a hyphen, so
+// joining the chunks (without their prefix and continuation marker)
+// gives back the original text, apart from the trimmed whitespace and
+// the line separators between original lines.
+type Chunker struct {
+	// MaxBytes is the maximum number of bytes in each chunk, including
+	// the prefix and continuation marker.
+	MaxBytes int
+	// Prefix is written at the start of every chunk, such as
+	// "PRIVMSG #channel :".
+	Prefix string
+	// Continuation is written at the end of every chunk that is
+	// continued by the next one, such as " …".
+	Continuation string
+	// TrimWhitespace strips leading and trailing whitespace from each
+	// chunk.
+	TrimWhitespace bool
+}
+
+// Chunk splits the input string into chunks of at most MaxBytes bytes.
+// Each line of the input starts a new chunk.
+//
+// Returns the chunks and a metadata sequence describing each of them, in
+// which OrigByteOffset and OrigRuneOffset locate the text of the chunk in
+// the original string and Width is the number of bytes of that text.
+func (c Chunker) Chunk(str string) ([]string, *WrappedStringSeq, error) {
+	budget := c.MaxBytes - len(c.Prefix) - len(c.Continuation)
+	if budget < utf8.UTFMax {
+		return nil, nil, errors.New(
+			"max bytes must leave room for a character after the prefix and continuation marker",
+		)
+	}
+
+	// every byte counts towards the budget, so escape sequences, tabs and
+	// controls are measured by their length and written as they are.
+	tokens := tokenize(str, MetricBytes.measurer())
+	for idx := range tokens {
+		token := &tokens[idx]
+		switch token.Kind {
+		case TokenANSI:
+			token.Width = len(token.Text)
+		case TokenTab, TokenControl:
+			token.Kind = TokenSpace
+			token.Width = len(token.Text)
+		}
+	}
+
+	config := wordWrapConfig{
+		limit:          budget,
+		trimWhitespace: c.TrimWhitespace,
+		splitWord:      true,
+		measurer:       MetricBytes.measurer(),
+		omitHyphen:     true,
+		// words are only split when they are longer than a chunk.
+		splitLongWordsOnly: true,
+	}
+	wrapped, seq, err := wrapTokensFrom(tokens, config, newPositions(1, 1, 0, 0))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	lines := strings.Split(wrapped, "\n")
+	chunks := make([]string, len(seq.WrappedLines))
+	for idx, wrappedLine := range seq.WrappedLines {
+		if wrappedLine.NotWithinLimit {
+			return nil, nil, errors.New("grapheme cluster is larger than the byte budget")
+		}
+
+		chunk := c.Prefix + lines[idx]
+		if !wrappedLine.LastSegmentInOrig {
+			chunk += c.Continuation
+		}
+		chunks[idx] = chunk
+	}
+	return chunks, seq, nil
+}
@@ -0,0 +1,106 @@
+package stringwrap
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// chunkTestCase is a struct that contains the input string, the chunker
+// configuration, and the expected chunks and their original offsets.
+type chunkTestCase struct {
+	input       string
+	chunker     Chunker
+	expected    []string
+	byteOffsets []LineOffset
+}
+
+// TestChunker tests splitting messages into chunks by byte budget with a
+// variety of test cases.
+func TestChunker(t *testing.T) {
+	tests := []chunkTestCase{
+		{
+			input:       "hello wörld, how are you?",
+			chunker:     Chunker{MaxBytes: 12},
+			expected:    []string{"hello ", "wörld, how ", "are you?"},
+			byteOffsets: []LineOffset{{Start: 0, End: 6}, {Start: 6, End: 18}, {Start: 18, End: 26}},
+		},
+		{
+			input:       "hello wörld, how are you?",
+			chunker:     Chunker{MaxBytes: 12, TrimWhitespace: true},
+			expected:    []string{"hello", "wörld, how", "are you?"},
+			byteOffsets: []LineOffset{{Start: 0, End: 6}, {Start: 6, End: 18}, {Start: 18, End: 26}},
+		},
+		{
+			input: "supercalifragilistic is long\nok",
+			chunker: Chunker{
+				MaxBytes: 16, Prefix: "> ", Continuation: " +", TrimWhitespace: true,
+			},
+			expected: []string{"> supercalifra +", "> gilistic is +", "> long", "> ok"},
+			byteOffsets: []LineOffset{
+				{Start: 0, End: 12}, {Start: 12, End: 24}, {Start: 24, End: 29}, {Start: 29, End: 31},
+			},
+		},
+		{
+			input:       "日本語のテキスト",
+			chunker:     Chunker{MaxBytes: 10, TrimWhitespace: true},
+			expected:    []string{"日本語", "のテキ", "スト"},
+			byteOffsets: []LineOffset{{Start: 0, End: 9}, {Start: 9, End: 18}, {Start: 18, End: 24}},
+		},
+		{
+			input:       "ab \x1b[31mcdef\x1b[0m gh",
+			chunker:     Chunker{MaxBytes: 12, TrimWhitespace: true},
+			expected:    []string{"ab \x1b[31mcdef", "\x1b[0m gh"},
+			byteOffsets: []LineOffset{{Start: 0, End: 12}, {Start: 12, End: 19}},
+		},
+		{
+			input:       "a\tb\tc\td e",
+			chunker:     Chunker{MaxBytes: 8},
+			expected:    []string{"a\tb\tc\td ", "e"},
+			byteOffsets: []LineOffset{{Start: 0, End: 8}, {Start: 8, End: 9}},
+		},
+		{
+			input:       "👩\u200D👩\u200D👧 ok",
+			chunker:     Chunker{MaxBytes: 18, TrimWhitespace: true},
+			expected:    []string{"👩\u200D👩\u200D👧", "ok"},
+			byteOffsets: []LineOffset{{Start: 0, End: 18}, {Start: 18, End: 21}},
+		},
+	}
+
+	for idx, tt := range tests {
+		t.Run(fmt.Sprintf("Chunker Test %d", idx+1), func(t *testing.T) {
+			chunks, seq, err := tt.chunker.Chunk(tt.input)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.expected, chunks)
+
+			byteOffsets := make([]LineOffset, 0, len(seq.WrappedLines))
+			for chunkIdx, wrappedLine := range seq.WrappedLines {
+				byteOffsets = append(byteOffsets, wrappedLine.OrigByteOffset)
+				assert.LessOrEqual(t, len(chunks[chunkIdx]), tt.chunker.MaxBytes)
+			}
+			assert.Equal(t, tt.byteOffsets, byteOffsets)
+
+			// without trimming, the chunks join back into the input.
+			if !tt.chunker.TrimWhitespace && tt.chunker.Prefix == "" && tt.chunker.Continuation == "" {
+				assert.Equal(t, tt.input, strings.Join(chunks, ""))
+			}
+		})
+	}
+}
+
+// TestChunker_Errors tests that budgets that cannot hold the text are
+// rejected.
+func TestChunker_Errors(t *testing.T) {
+	_, _, err := Chunker{MaxBytes: 6, Prefix: "abc"}.Chunk("x")
+	assert.NotNil(t, err)
+
+	_, _, err = Chunker{MaxBytes: 6, TrimWhitespace: true}.Chunk("👩\u200D👩\u200D👧")
+	assert.NotNil(t, err)
+
+	chunks, seq, err := Chunker{MaxBytes: 6}.Chunk("")
+	assert.Nil(t, err)
+	assert.Empty(t, chunks)
+	assert.Empty(t, seq.WrappedLines)
+}
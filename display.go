@@ -0,0 +1,225 @@
+package stringwrap
+
+import (
+	"strings"
+
+	"github.com/rivo/uniseg"
+)
+
+// Alignment determines where a string is placed within a wider column.
+type Alignment int
+
+const (
+	// AlignLeft pads the string on the right.
+	AlignLeft Alignment = iota
+	// AlignCenter pads the string evenly on both sides, with any odd
+	// column of padding on the right.
+	AlignCenter
+	// AlignRight pads the string on the left.
+	AlignRight
+)
+
+// walkCells splits a single line into grapheme clusters, whitespace and
+// ANSI escape sequences, passing each to yield along with its viewable
+// width and whether it is an escape sequence. The widths are those used
+// when wrapping with the measurer.
+func walkCells(str string, m Measurer, yield func(text string, width int, ansi bool)) {
+	tokenizeFunc(str, m, func(token Token) {
+		switch token.Kind {
+		case TokenWord:
+			graphemes := uniseg.NewGraphemes(token.Text)
+			for graphemes.Next() {
+				yield(graphemes.Str(), measureWidth(m, graphemes.Str()), false)
+			}
+		case TokenANSI:
+			yield(token.Text, 0, true)
+		default:
+			yield(token.Text, token.Width, false)
+		}
+	})
+}
+
+// closeStyle appends a reset to the string if it leaves styles open
+func closeStyle(str string) string {
+	if activeStyle(str) != "" {
+		return str + ansiReset
+	}
+	return str
+}
+
+// Width returns the viewable width of a single line, ignoring ANSI escape
+// sequences and measuring grapheme clusters exactly as wrapping does.
+// Tabs are not expanded and have no width.
+func Width(str string) int {
+	return cellsWidth(str, nil)
+}
+
+// Width returns the viewable width of a single line like Width, measured
+// in the metric and with the measurer of the wrapper.
+func (w Wrapper) Width(str string) int {
+	return cellsWidth(str, w.measurer())
+}
+
+// cellsWidth returns the viewable width of a single line as measured by
+// the measurer, ignoring ANSI escape sequences
+func cellsWidth(str string, m Measurer) int {
+	width := 0
+	walkCells(str, m, func(_ string, w int, _ bool) { width += w })
+	return width
+}
+
+// Truncate shortens a single line to at most width columns, ending it
+// with the tail (such as "…") if anything was removed. Grapheme clusters
+// and ANSI escape sequences are never split, and styles left open by the
+// removed text are closed. A negative width is treated as zero.
+func Truncate(str string, width int, tail string) string {
+	return truncateCells(str, nil, width, tail)
+}
+
+// Truncate shortens a single line like Truncate, measured in the metric
+// and with the measurer of the wrapper.
+func (w Wrapper) Truncate(str string, width int, tail string) string {
+	return truncateCells(str, w.measurer(), width, tail)
+}
+
+// truncateCells shortens a single line like Truncate, measuring widths
+// with the measurer
+func truncateCells(str string, m Measurer, width int, tail string) string {
+	width = max(width, 0)
+	if cellsWidth(str, m) <= width {
+		return str
+	}
+
+	// a tail that does not fit is itself truncated.
+	budget := width - cellsWidth(tail, m)
+	if budget < 0 {
+		return truncateCells(tail, m, width, "")
+	}
+
+	var truncated strings.Builder
+	col := 0
+	walkCells(str, m, func(text string, w int, _ bool) {
+		// once a cluster does not fit, nothing after it is kept.
+		if col+w > budget {
+			budget = -1
+			return
+		}
+		truncated.WriteString(text)
+		col += w
+	})
+	truncated.WriteString(tail)
+	return closeStyle(truncated.String())
+}
+
+// Pad pads a single line with spaces to width columns, placing it
+// according to the alignment. Lines that are already at least width
+// columns wide are returned unchanged.
+func Pad(str string, width int, align Alignment) string {
+	return padLine(str, nil, width-Width(str), align)
+}
+
+// Pad pads a single line like Pad, measured in the metric and with the
+// measurer of the wrapper.
+func (w Wrapper) Pad(str string, width int, align Alignment) string {
+	return padLine(str, w.measurer(), width-w.Width(str), align)
+}
+
+// padLine pads a single line with spaces filling the given number of
+// columns as measured by the measurer, placing it according to the
+// alignment
+func padLine(str string, m Measurer, padding int, align Alignment) string {
+	if padding <= 0 {
+		return str
+	}
+	spaces := blank(m, padding)
+	switch align {
+	case AlignRight:
+		return spaces + str
+	case AlignCenter:
+		left := len(spaces) / 2
+		return spaces[:left] + str + spaces[left:]
+	default:
+		return str + spaces
+	}
+}
+
+// blank returns the spaces that fill at most the given number of columns
+// as measured by the measurer
+func blank(m Measurer, columns int) string {
+	space := measureWidth(m, " ")
+	if space <= 0 {
+		space = 1
+	}
+	return strings.Repeat(" ", max(columns, 0)/space)
+}
+
+// Align pads every line of a string, such as the output of StringWrap,
+// to width columns according to the alignment. A trailing new line is
+// kept without padding the empty line after it.
+func Align(str string, width int, align Alignment) string {
+	return alignLines(str, nil, width, align)
+}
+
+// Align pads every line of a string like Align, measured in the metric
+// and with the measurer of the wrapper, such as the output of Wrap.
+func (w Wrapper) Align(str string, width int, align Alignment) string {
+	return alignLines(str, w.measurer(), width, align)
+}
+
+// alignLines pads every line of a string like Align, measuring widths
+// with the measurer
+func alignLines(str string, m Measurer, width int, align Alignment) string {
+	lines := strings.Split(strings.TrimSuffix(str, "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = padLine(line, m, width-cellsWidth(line, m), align)
+	}
+	aligned := strings.Join(lines, "\n")
+	if strings.HasSuffix(str, "\n") {
+		aligned += "\n"
+	}
+	return aligned
+}
+
+// Slice returns the columns [x, x+width) of a single line, such as for
+// horizontal scrolling. A wide grapheme cluster that is cut by either
+// edge is replaced by spaces for the columns that fall inside the slice.
+// The styles in effect at column x are applied to the slice, and any
+// left open at its end are closed.
+func Slice(str string, x int, width int) string {
+	return sliceCells(str, nil, x, width)
+}
+
+// Slice returns the columns [x, x+width) of a single line like Slice,
+// measured in the metric and with the measurer of the wrapper.
+func (w Wrapper) Slice(str string, x int, width int) string {
+	return sliceCells(str, w.measurer(), x, width)
+}
+
+// sliceCells returns the columns [x, x+width) of a single line like
+// Slice, measuring widths with the measurer
+func sliceCells(str string, m Measurer, x int, width int) string {
+	var before, slice strings.Builder
+	end := x + width
+	col := 0
+	walkCells(str, m, func(text string, w int, ansi bool) {
+		switch {
+		case ansi && col < x:
+			before.WriteString(text)
+		case ansi:
+			if col < end {
+				slice.WriteString(text)
+			}
+		case col >= x && col+w <= end && (w > 0 || col < end):
+			slice.WriteString(text)
+		case col < x && col+w > x:
+			slice.WriteString(blank(m, min(col+w, end)-x))
+		case col >= x && col < end:
+			slice.WriteString(blank(m, end-col))
+		}
+		col += w
+	})
+	if slice.Len() == 0 {
+		return ""
+	}
+	return closeStyle(activeStyle(before.String()) + slice.String())
+}
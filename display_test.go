@@ -0,0 +1,165 @@
+package stringwrap
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestWidth tests the Width function with a variety of test cases.
+func TestWidth(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int
+	}{
+		{input: "hello world", expected: 11},
+		{input: "\x1b[31mred\x1b[0m", expected: 3},
+		{input: "🌟 stars", expected: 8},
+		{input: "👩‍👩‍👧 family", expected: 9},
+		{input: "日本語", expected: 6},
+		{input: "yo\u00A0u", expected: 4},
+		{input: "", expected: 0},
+	}
+
+	for idx, tt := range tests {
+		t.Run(fmt.Sprintf("Width Test %d", idx+1), func(t *testing.T) {
+			assert.Equal(t, tt.expected, Width(tt.input))
+		})
+	}
+}
+
+// TestTruncate tests the Truncate function with a variety of test cases.
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		input    string
+		width    int
+		tail     string
+		expected string
+	}{
+		{input: "hello world", width: 11, tail: "…", expected: "hello world"},
+		{input: "hello world", width: 8, tail: "…", expected: "hello w…"},
+		{input: "hello world", width: 8, tail: "", expected: "hello wo"},
+		{input: "日本語テキスト", width: 7, tail: "…", expected: "日本語…"},
+		{input: "日本語テキスト", width: 6, tail: "…", expected: "日本…"},
+		{input: "👩‍👩‍👧👩‍👩‍👧", width: 3, tail: "", expected: "👩‍👩‍👧"},
+		{
+			input:    "\x1b[31mred text\x1b[0m plain",
+			width:    6,
+			tail:     "...",
+			expected: "\x1b[31mred...\x1b[0m",
+		},
+		{
+			input:    "\x1b[31mred\x1b[0m plain text",
+			width:    7,
+			tail:     "...",
+			expected: "\x1b[31mred\x1b[0m ...",
+		},
+		{input: "hello world", width: 2, tail: "...", expected: ".."},
+	}
+
+	for idx, tt := range tests {
+		t.Run(fmt.Sprintf("Truncate Test %d", idx+1), func(t *testing.T) {
+			truncated := Truncate(tt.input, tt.width, tt.tail)
+			assert.Equal(t, tt.expected, truncated)
+			assert.LessOrEqual(t, Width(truncated), tt.width)
+		})
+	}
+
+	assert.Equal(t, "", Truncate("abc", -1, ""))
+	assert.Equal(t, "", Truncate("abc", -3, "…"))
+	assert.Equal(t, "\x1b[31m", Truncate("\x1b[31m", -1, ""))
+}
+
+// TestPad tests the Pad and Align functions with a variety of test cases.
+func TestPad(t *testing.T) {
+	tests := []struct {
+		input    string
+		width    int
+		align    Alignment
+		expected string
+	}{
+		{input: "abc", width: 6, align: AlignLeft, expected: "abc   "},
+		{input: "abc", width: 6, align: AlignRight, expected: "   abc"},
+		{input: "abc", width: 6, align: AlignCenter, expected: " abc  "},
+		{input: "🌟", width: 5, align: AlignCenter, expected: " 🌟  "},
+		{input: "\x1b[1mab\x1b[0m", width: 4, align: AlignRight, expected: "  \x1b[1mab\x1b[0m"},
+		{input: "abcdef", width: 4, align: AlignLeft, expected: "abcdef"},
+	}
+
+	for idx, tt := range tests {
+		t.Run(fmt.Sprintf("Pad Test %d", idx+1), func(t *testing.T) {
+			assert.Equal(t, tt.expected, Pad(tt.input, tt.width, tt.align))
+		})
+	}
+
+	wrapped, _, _ := StringWrap("The quick brown fox jumps\n", 10, 4, true)
+	assert.Equal(t, " The quick\n brown fox\n     jumps\n", Align(wrapped, 10, AlignRight))
+}
+
+// TestSlice tests the Slice function with a variety of test cases.
+func TestSlice(t *testing.T) {
+	tests := []struct {
+		input    string
+		x        int
+		width    int
+		expected string
+	}{
+		{input: "hello world", x: 0, width: 5, expected: "hello"},
+		{input: "hello world", x: 6, width: 10, expected: "world"},
+		{input: "hello world", x: 20, width: 5, expected: ""},
+		{input: "日本語", x: 1, width: 4, expected: " 本 "},
+		{input: "日本語", x: 2, width: 2, expected: "本"},
+		{input: "a🌟b", x: 0, width: 2, expected: "a "},
+		{
+			input:    "\x1b[31mred \x1b[1mbold\x1b[0m plain",
+			x:        5,
+			width:    5,
+			expected: "\x1b[31m\x1b[1mold\x1b[0m p",
+		},
+		{
+			input:    "\x1b[31mred text",
+			x:        1,
+			width:    2,
+			expected: "\x1b[31med\x1b[0m",
+		},
+	}
+
+	for idx, tt := range tests {
+		t.Run(fmt.Sprintf("Slice Test %d", idx+1), func(t *testing.T) {
+			slice := Slice(tt.input, tt.x, tt.width)
+			assert.Equal(t, tt.expected, slice)
+			assert.LessOrEqual(t, Width(slice), tt.width)
+		})
+	}
+}
+
+// TestWrapper_Display tests that the display helpers of a wrapper measure
+// lines exactly as the wrapper does under a custom measurer and metric.
+func TestWrapper_Display(t *testing.T) {
+	doubleWidth := WidthFunc(func(str string) int { return 2 * Width(str) })
+	wrapper := Wrapper{Limit: 12, TabSize: 4, TrimWhitespace: true, Measurer: doubleWidth}
+
+	wrapped, seq, err := wrapper.Wrap("The \x1b[31mquick\x1b[0m brown fox")
+	assert.Nil(t, err)
+	lines := strings.Split(wrapped, "\n")
+	for idx, wrappedLine := range seq.WrappedLines {
+		assert.Equal(t, wrappedLine.Width, wrapper.Width(lines[idx]))
+		assert.Equal(t, 12, wrapper.Width(wrapper.Pad(lines[idx], 12, AlignRight)))
+		assert.LessOrEqual(t, wrapper.Width(wrapper.Truncate(lines[idx], 5, "…")), 5)
+	}
+	for _, line := range strings.Split(wrapper.Align(wrapped, 12, AlignCenter), "\n") {
+		assert.Equal(t, 12, wrapper.Width(line))
+	}
+
+	assert.Equal(t, "he…", wrapper.Truncate("hello", 6, "…"))
+	assert.Equal(t, "hello", wrapper.Truncate("hello", 10, "…"))
+	assert.Equal(t, "hi  ", wrapper.Pad("hi", 8, AlignLeft))
+	assert.Equal(t, "el", wrapper.Slice("hello", 2, 4))
+	assert.Equal(t, "l", wrapper.Slice("hello", 3, 4))
+	assert.Equal(t, " ", wrapper.Slice("日本", 0, 2))
+
+	assert.Equal(t, 2, Wrapper{Metric: MetricUTF16}.Width("🌟"))
+	assert.Equal(t, 3, Wrapper{Metric: MetricBytes}.Width("\x1b[1mé!"))
+}
@@ -0,0 +1,68 @@
+package stringwrap
+
+import "errors"
+
+// FitLines finds the narrowest limit at which the input string wraps to
+// at most maxLines lines, searching limits no greater than maxLimit. The
+// Limit of the wrapper is ignored.
+//
+// Returns the string wrapped at that limit and its metadata sequence,
+// whose Limit field holds the limit found. An error is returned if the
+// string does not fit within maxLines lines even at maxLimit.
+//
+// Limits narrower than the min-content width of the string are never
+// chosen, so no wrapped line exceeds the limit unless maxLimit itself is
+// too narrow. If SplitWord is set, words may be split to reach a narrower
+// limit, exactly as StringWrapSplit would split them.
+func (w Wrapper) FitLines(str string, maxLines int, maxLimit int) (
+	string, *WrappedStringSeq, error,
+) {
+	if maxLines < 1 {
+		return "", nil, errors.New("max lines must be greater than zero")
+	}
+	if maxLimit < 2 {
+		return "", nil, errors.New("max limit must be greater than one")
+	}
+
+	tokens := tokenize(str, w.measurer())
+	tokensFunc := func(yield func(Token)) {
+		for _, token := range tokens {
+			yield(token)
+		}
+	}
+
+	// the number of wrapped lines never increases as the limit grows, so
+	// the narrowest limit that fits is found with a binary search between
+	// the intrinsic widths of the string.
+	// no limit narrower than two, plus the width of the continuation
+	// markers, can be wrapped to.
+	config := w.config()
+	floor := 2 + config.leadingWidth + config.trailingWidth
+	if maxLimit < floor {
+		return "", nil, errors.New("max limit must leave room for the continuation markers")
+	}
+	minWidth, maxWidth := intrinsicWidths(config, tokensFunc)
+	lo := min(max(minWidth, floor), maxLimit)
+	hi := min(max(maxWidth+config.trailingWidth, floor), maxLimit)
+	fits := func(limit int) bool {
+		config.limit = limit
+		return measureTokens(config, false, tokensFunc).Lines <= maxLines
+	}
+
+	if lo > hi || !fits(hi) {
+		hi = maxLimit
+		if !fits(hi) {
+			return "", nil, errors.New("string does not fit within max lines")
+		}
+	}
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		if fits(mid) {
+			hi = mid
+		} else {
+			lo = mid + 1
+		}
+	}
+	config.limit = hi
+	return wrapTokensFrom(tokens, config, newPositions(1, 1, 0, 0))
+}
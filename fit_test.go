@@ -0,0 +1,132 @@
+package stringwrap
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fitLinesTestCase is a struct that contains the input string, the
+// wrapper configuration, the line and limit bounds, and the expected
+// limit found.
+type fitLinesTestCase struct {
+	input    string
+	wrapper  Wrapper
+	maxLines int
+	maxLimit int
+	limit    int
+	err      bool
+}
+
+// TestWrapper_FitLines tests the FitLines function with a variety of test
+// cases, checking that the limit found is the narrowest that fits.
+func TestWrapper_FitLines(t *testing.T) {
+	tests := []fitLinesTestCase{
+		{
+			input:    "The quick brown fox jumps over the lazy dog",
+			wrapper:  Wrapper{TabSize: 4, TrimWhitespace: true},
+			maxLines: 2,
+			maxLimit: 80,
+			limit:    23,
+		},
+		{
+			input:    "The quick brown fox jumps over the lazy dog",
+			wrapper:  Wrapper{TabSize: 4, TrimWhitespace: true},
+			maxLines: 1,
+			maxLimit: 80,
+			limit:    43,
+		},
+		{
+			input:    "The quick brown fox jumps over the lazy dog",
+			wrapper:  Wrapper{TabSize: 4, TrimWhitespace: true},
+			maxLines: 20,
+			maxLimit: 80,
+			limit:    5,
+		},
+		{
+			input:    "Supercalifragilisticexpialidocious",
+			wrapper:  Wrapper{TabSize: 4, TrimWhitespace: true, SplitWord: true},
+			maxLines: 3,
+			maxLimit: 80,
+			limit:    12,
+		},
+		{
+			input:    "\x1b[31mred 🌟 stars\x1b[0m\nand a second line",
+			wrapper:  Wrapper{TabSize: 4, TrimWhitespace: true},
+			maxLines: 3,
+			maxLimit: 80,
+			limit:    12,
+		},
+		{
+			input:    "one\ntwo\nthree",
+			wrapper:  Wrapper{TabSize: 4, TrimWhitespace: true},
+			maxLines: 2,
+			maxLimit: 80,
+			err:      true,
+		},
+		{
+			input:    "The quick brown fox jumps over the lazy dog",
+			wrapper:  Wrapper{TabSize: 4, TrimWhitespace: true},
+			maxLines: 2,
+			maxLimit: 20,
+			err:      true,
+		},
+		{
+			input: "日本 日本 日本",
+			wrapper: Wrapper{
+				TrimWhitespace: true, SplitWord: true, LeadingMarker: "> ", TrailingMarker: "\\",
+			},
+			maxLines: 3,
+			maxLimit: 11,
+			limit:    7,
+		},
+		{
+			input:    "",
+			wrapper:  Wrapper{TrimWhitespace: true, TrailingMarker: "\\"},
+			maxLines: 1,
+			maxLimit: 3,
+			limit:    3,
+		},
+		{
+			input:    "日本 日本 日本",
+			wrapper:  Wrapper{SplitWord: true, LeadingMarker: "> ", TrailingMarker: "\\"},
+			maxLines: 3,
+			maxLimit: 4,
+			err:      true,
+		},
+	}
+
+	for idx, tt := range tests {
+		t.Run(fmt.Sprintf("Fit Lines Test %d", idx+1), func(t *testing.T) {
+			wrapped, seq, err := tt.wrapper.FitLines(tt.input, tt.maxLines, tt.maxLimit)
+			if tt.err {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tt.limit, seq.Limit)
+			assert.LessOrEqual(t, len(seq.WrappedLines), tt.maxLines)
+
+			wrapper := tt.wrapper
+			wrapper.Limit = tt.limit
+			expected, expectedSeq, _ := wrapper.Wrap(tt.input)
+			assert.Equal(t, expected, wrapped)
+			assert.Equal(t, expectedSeq, seq)
+
+			// no narrower limit fits the string without overflowing.
+			for limit := 2; limit < tt.limit; limit++ {
+				wrapper.Limit = limit
+				_, narrowSeq, err := wrapper.Wrap(tt.input)
+				if err != nil {
+					continue
+				}
+				overflows := false
+				for _, wrappedLine := range narrowSeq.WrappedLines {
+					overflows = overflows || wrappedLine.NotWithinLimit
+				}
+				assert.True(t, overflows || len(narrowSeq.WrappedLines) > tt.maxLines)
+			}
+		})
+	}
+}
@@ -0,0 +1,409 @@
+package stringwrap
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/galactixx/ansiwalker"
+)
+
+// fenwick is a binary indexed tree over a slice of non-negative
+// integers, supporting prefix sums and point updates in O(log n).
+type fenwick []int
+
+// newFenwick builds a fenwick tree over the given values in O(n)
+func newFenwick(values []int) fenwick {
+	f := make(fenwick, len(values)+1)
+	for i, v := range values {
+		f[i+1] += v
+		if parent := i + 1 + ((i + 1) & -(i + 1)); parent < len(f) {
+			f[parent] += f[i+1]
+		}
+	}
+	return f
+}
+
+// add adds delta to the value at index i
+func (f fenwick) add(i int, delta int) {
+	for i++; i < len(f); i += i & -i {
+		f[i] += delta
+	}
+}
+
+// prefix returns the sum of the values in [0, i)
+func (f fenwick) prefix(i int) int {
+	sum := 0
+	for ; i > 0; i -= i & -i {
+		sum += f[i]
+	}
+	return sum
+}
+
+// search returns the smallest index i such that prefix(i+1) > n, or the
+// number of values if the total sum is not greater than n
+func (f fenwick) search(n int) int {
+	idx := 0
+	step := 1
+	for step*2 < len(f) {
+		step *= 2
+	}
+	for ; step > 0; step /= 2 {
+		if idx+step < len(f) && f[idx+step] <= n {
+			idx += step
+			n -= f[idx]
+		}
+	}
+	return idx
+}
+
+// indexedLine holds a single original line of a LineIndex
+type indexedLine struct {
+	// the text of the line, including its trailing hard break
+	text string
+	// the trailing hard break of the line, if any
+	sep string
+	// the tokens of the line with line-relative offsets, which are
+	// only computed once the line is first wrapped
+	tokens []Token
+}
+
+// lineTokens returns the tokens of the line, tokenizing it with the
+// measurer if needed
+func (l *indexedLine) lineTokens(m Measurer) []Token {
+	if l.tokens == nil {
+		l.tokens = tokenize(l.text, m)
+	}
+	return l.tokens
+}
+
+// origLineEnd returns the byte offset where the original line starting at
+// start ends, just after its hard break, along with the offset where the
+// hard break starts. If the line has no hard break, both are the length
+// of the string.
+func origLineEnd(str string, start int) (end int, sepStart int) {
+	idx := start
+	for idx < len(str) {
+		r, rSize, next, _ := ansiwalker.ANSIWalk(str, idx)
+		if next < 0 {
+			break
+		}
+		// the rune after an escape sequence may start another one, so
+		// the walk resumes from it as the tokenizer does.
+		if rIdx := next - rSize; rIdx > idx {
+			idx = rIdx
+			continue
+		}
+		idx = next
+		// a carriage return followed by a line feed is a single break.
+		if r == '\r' && idx < len(str) && str[idx] == '\n' {
+			continue
+		}
+		if isHardBreak(r) {
+			sepStart = idx - rSize
+			if r == '\n' && sepStart > start && str[sepStart-1] == '\r' {
+				sepStart--
+			}
+			return idx, sepStart
+		}
+	}
+	return len(str), len(str)
+}
+
+// splitOrigLines splits the string into original lines at each hard
+// break, keeping the hard break at the end of its line
+func splitOrigLines(str string) []*indexedLine {
+	var lines []*indexedLine
+	for start := 0; start < len(str); {
+		end, sepStart := origLineEnd(str, start)
+		lines = append(lines, &indexedLine{
+			text: str[start:end],
+			sep:  str[sepStart:end],
+		})
+		start = end
+	}
+	return lines
+}
+
+// shiftTokens returns a copy of the tokens with their offsets moved by
+// the given byte and rune amounts
+func shiftTokens(tokens []Token, byteIdx int, runeIdx int) []Token {
+	shifted := make([]Token, len(tokens))
+	for i, token := range tokens {
+		token.ByteOffset.Start += byteIdx
+		token.ByteOffset.End += byteIdx
+		token.RuneOffset.Start += runeIdx
+		token.RuneOffset.End += runeIdx
+		shifted[i] = token
+	}
+	return shifted
+}
+
+// LineIndex provides random access to the wrapped lines of a large
+// string without wrapping the whole string up front.
+//
+// The index records how many wrapped lines each original line produces
+// at the configured limit. Original lines are only tokenized and wrapped
+// when a lookup needs them, so jumping to wrapped line N only wraps the
+// original lines up to N once, and scrolling back over lines that have
+// already been counted is O(log n). Editing an original line only
+// invalidates the count of that line.
+type LineIndex struct {
+	config wordWrapConfig
+	lines  []*indexedLine
+	// the number of wrapped lines produced by each original line
+	counts []int
+	// the number of bytes of wrapped output produced by each original
+	// line, including the separators after its wrapped lines
+	wrappedLens []int
+	// the number of leading original lines whose counts are known
+	known int
+	// original lines before known whose counts must be recomputed
+	dirty map[int]struct{}
+
+	countTree   fenwick
+	byteTree    fenwick
+	runeTree    fenwick
+	wrappedTree fenwick
+}
+
+// NewLineIndex creates a LineIndex for the input string, wrapping to the
+// specified viewable-width limit with the same semantics as StringWrap,
+// or StringWrapSplit if splitWord is true.
+func NewLineIndex(
+	str string, limit int, tabSize int, trimWhitespace bool, splitWord bool,
+) (*LineIndex, error) {
+	return Wrapper{
+		Limit:          limit,
+		TabSize:        tabSize,
+		TrimWhitespace: trimWhitespace,
+		SplitWord:      splitWord,
+	}.NewLineIndex(str)
+}
+
+// NewLineIndex creates a LineIndex for the input string, wrapping with
+// the configuration of the wrapper, including its Measurer or Metric.
+//
+// The index wraps each original line on its own, so an error is returned
+// if the wrapper reflows paragraphs, collapses hard breaks, truncates to
+// MaxLines, or does not treat carriage returns as hard breaks.
+func (w Wrapper) NewLineIndex(str string) (*LineIndex, error) {
+	if w.Reflow || w.MaxLines > 0 || w.WhiteSpace == WhiteSpaceNormal ||
+		w.CarriageReturn != CarriageReturnBreak {
+		return nil, errors.New("wrapper configuration cannot be indexed by original line")
+	}
+	config := w.config()
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+
+	li := &LineIndex{
+		config: config,
+		lines:  splitOrigLines(str),
+		dirty:  make(map[int]struct{}),
+	}
+	li.rebuild()
+	return li, nil
+}
+
+// rebuild recreates the fenwick trees from the original lines and the
+// counts computed so far
+func (li *LineIndex) rebuild() {
+	byteLens := make([]int, len(li.lines))
+	runeLens := make([]int, len(li.lines))
+	for i, line := range li.lines {
+		byteLens[i] = len(line.text)
+		runeLens[i] = utf8.RuneCountInString(line.text)
+	}
+	li.counts = append(li.counts, make([]int, len(li.lines)-len(li.counts))...)
+	li.wrappedLens = append(li.wrappedLens, make([]int, len(li.lines)-len(li.wrappedLens))...)
+	li.countTree = newFenwick(li.counts)
+	li.wrappedTree = newFenwick(li.wrappedLens)
+	li.byteTree = newFenwick(byteLens)
+	li.runeTree = newFenwick(runeLens)
+}
+
+// countLine measures an original line and records its wrapped line count
+func (li *LineIndex) countLine(i int) {
+	tokens := li.lines[i].lineTokens(li.config.measurer)
+	stateMachine := measureState(li.config, false, func(yield func(Token)) {
+		for _, token := range tokens {
+			yield(token)
+		}
+	})
+	count, wrappedLen := stateMachine.measurement.Lines, stateMachine.pos.wrappedByte
+	li.countTree.add(i, count-li.counts[i])
+	li.counts[i] = count
+	li.wrappedTree.add(i, wrappedLen-li.wrappedLens[i])
+	li.wrappedLens[i] = wrappedLen
+}
+
+// ensure computes the counts of the original lines needed to locate
+// wrapped line n, or of every original line if n is past the end
+func (li *LineIndex) ensure(n int) {
+	for i := range li.dirty {
+		li.countLine(i)
+		delete(li.dirty, i)
+	}
+	for li.known < len(li.lines) && li.countTree.prefix(li.known) < n {
+		li.countLine(li.known)
+		li.known++
+	}
+}
+
+// OrigLineCount returns the number of original lines in the string.
+func (li *LineIndex) OrigLineCount() int { return len(li.lines) }
+
+// Len returns the total number of wrapped lines. This requires the count
+// of every original line, so the whole string is wrapped the first time
+// it is called.
+func (li *LineIndex) Len() int {
+	li.ensure(int(^uint(0) >> 1))
+	return li.countTree.prefix(len(li.lines))
+}
+
+// Locate returns the original line number and the segment within that
+// original line of wrapped line n, where both n and the original line
+// number start at one. If n is out of range, ok is false.
+func (li *LineIndex) Locate(n int) (origLine int, segment int, ok bool) {
+	if n < 1 {
+		return 0, 0, false
+	}
+	li.ensure(n)
+	i := li.countTree.search(n - 1)
+	if i >= li.known {
+		return 0, 0, false
+	}
+	return i + 1, n - li.countTree.prefix(i), true
+}
+
+// Lines returns up to count wrapped lines starting at wrapped line
+// start, along with their metadata. Only the original lines that overlap
+// the requested window are wrapped. The metadata is identical to what
+// StringWrap would produce for the same lines of the whole string.
+func (li *LineIndex) Lines(start int, count int) ([]string, []WrappedString, error) {
+	if start < 1 {
+		return nil, nil, errors.New("start must be greater than zero")
+	}
+	if count < 0 {
+		return nil, nil, errors.New("count must not be negative")
+	}
+
+	end := start + count
+	li.ensure(end - 1)
+
+	var lines []string
+	var wrappedLines []WrappedString
+	for i := li.countTree.search(start - 1); i < li.known; i++ {
+		firstLineNum := li.countTree.prefix(i) + 1
+		if firstLineNum >= end {
+			break
+		}
+
+		// wrap the original line using its global offsets and line
+		// numbers, so the metadata matches a wrap of the whole string.
+		byteIdx, runeIdx := li.byteTree.prefix(i), li.runeTree.prefix(i)
+		pos := newPositions(firstLineNum, i+1, byteIdx, runeIdx)
+		pos.wrappedByte = li.wrappedTree.prefix(i)
+		tokens := shiftTokens(li.lines[i].lineTokens(li.config.measurer), byteIdx, runeIdx)
+
+		// a last original line that wraps to no lines, such as trimmed
+		// whitespace, is still spanned by the line before it.
+		if lastIdx := len(li.lines) - 1; i == lastIdx-1 {
+			if li.known == lastIdx {
+				li.countLine(lastIdx)
+				li.known++
+			}
+			if li.counts[lastIdx] == 0 {
+				tokens = append(tokens, shiftTokens(
+					li.lines[lastIdx].lineTokens(li.config.measurer),
+					li.byteTree.prefix(lastIdx),
+					li.runeTree.prefix(lastIdx),
+				)...)
+			}
+		}
+		wrapped, seq, err := wrapTokensFrom(tokens, li.config, pos)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for _, wrappedLine := range seq.WrappedLines {
+			if wrappedLine.CurLineNum >= start && wrappedLine.CurLineNum < end {
+				offset := wrappedLine.WrappedByteOffset
+				lines = append(lines, wrapped[offset.Start-pos.wrappedByte:offset.End-pos.wrappedByte])
+				wrappedLines = append(wrappedLines, wrappedLine)
+			}
+		}
+	}
+	return lines, wrappedLines, nil
+}
+
+// SetLine replaces the text of an original line, where origLine starts
+// at one, keeping its trailing hard break. Only the wrapped line count of
+// the edited line is invalidated. If the new text contains hard breaks,
+// the original line is replaced by several original lines, and if it
+// starts with a line feed after a carriage return, the two are joined
+// into a single hard break.
+func (li *LineIndex) SetLine(origLine int, text string) error {
+	i := origLine - 1
+	if i < 0 || i >= len(li.lines) {
+		return errors.New("original line out of range")
+	}
+
+	// a carriage return that ends the previous line and a line feed that
+	// starts the new text are a single hard break, so the previous line
+	// is split again along with the edited line.
+	first, last := i, i+1
+	region := text + li.lines[i].sep
+	if first > 0 && li.lines[first-1].sep == "\r" && strings.HasPrefix(region, "\n") {
+		first--
+		region = li.lines[first].text + region
+	}
+	parts := splitOrigLines(region)
+	if len(parts) == 0 {
+		parts = []*indexedLine{{}}
+	}
+
+	// the common case of an edit within a single line only requires
+	// updating the lengths and invalidating the count of that line.
+	if len(parts) == 1 && last-first == 1 {
+		old := li.lines[i].text
+		li.lines[i] = parts[0]
+		li.byteTree.add(i, len(parts[0].text)-len(old))
+		li.runeTree.add(
+			i,
+			utf8.RuneCountInString(parts[0].text)-utf8.RuneCountInString(old),
+		)
+		if i < li.known {
+			li.dirty[i] = struct{}{}
+		}
+		return nil
+	}
+
+	// otherwise splice the new lines in, shifting the known counts and
+	// invalidated lines that come after the replaced lines.
+	delta := len(parts) - (last - first)
+	li.lines = append(li.lines[:first], append(parts, li.lines[last:]...)...)
+	li.counts = append(li.counts[:first], append(make([]int, len(parts)), li.counts[last:]...)...)
+	li.wrappedLens = append(
+		li.wrappedLens[:first], append(make([]int, len(parts)), li.wrappedLens[last:]...)...,
+	)
+
+	dirty := make(map[int]struct{}, len(li.dirty)+len(parts))
+	for j := range li.dirty {
+		if j >= last {
+			dirty[j+delta] = struct{}{}
+		} else if j < first {
+			dirty[j] = struct{}{}
+		}
+	}
+	if first < li.known {
+		for j := first; j < first+len(parts); j++ {
+			dirty[j] = struct{}{}
+		}
+		li.known = max(li.known+delta, first+len(parts))
+	}
+	li.dirty = dirty
+	li.rebuild()
+	return nil
+}
@@ -0,0 +1,214 @@
+package stringwrap
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// indexTestInput is a multi-line input string used by the LineIndex tests
+const indexTestInput = "The quick brown fox jumps over the lazy dog\n\n" +
+	"\tHello world! 🌟 with \x1b[31mcolored\x1b[0m words\r\n" +
+	"Supercalifragilisticexpialidocious\u2028short\nend"
+
+// TestLineIndex_Lines tests that every window of wrapped lines returned by
+// the LineIndex matches the result of wrapping the whole string.
+func TestLineIndex_Lines(t *testing.T) {
+	for _, splitWord := range []bool{false, true} {
+		wrapped, seq, _ := stringWrap(indexTestInput, 10, 4, true, splitWord)
+		expected := strings.Split(wrapped, "\n")
+
+		for start := 1; start <= len(seq.WrappedLines)+1; start++ {
+			for _, count := range []int{0, 1, 3, 100} {
+				name := fmt.Sprintf("Line Index Test %t/%d/%d", splitWord, start, count)
+				t.Run(name, func(t *testing.T) {
+					li, err := NewLineIndex(indexTestInput, 10, 4, true, splitWord)
+					assert.Nil(t, err)
+
+					lines, wrappedLines, err := li.Lines(start, count)
+					assert.Nil(t, err)
+
+					end := min(start-1+count, len(seq.WrappedLines))
+					if start-1 >= end {
+						assert.Empty(t, wrappedLines)
+						return
+					}
+					assert.Equal(t, expected[start-1:end], lines)
+					assert.Equal(t, seq.WrappedLines[start-1:end], wrappedLines)
+				})
+			}
+		}
+	}
+}
+
+// TestLineIndex_Locate tests that wrapped line numbers are mapped to the
+// correct original line and segment.
+func TestLineIndex_Locate(t *testing.T) {
+	_, seq, _ := StringWrap(indexTestInput, 10, 4, true)
+	li, _ := NewLineIndex(indexTestInput, 10, 4, true, false)
+
+	// locate the lines in reverse to check that scrolling backward works
+	// after the counts have been computed.
+	for idx := len(seq.WrappedLines) - 1; idx >= 0; idx-- {
+		wrappedLine := seq.WrappedLines[idx]
+		t.Run(fmt.Sprintf("Locate Test %d", idx+1), func(t *testing.T) {
+			origLine, segment, ok := li.Locate(wrappedLine.CurLineNum)
+			assert.True(t, ok)
+			assert.Equal(t, wrappedLine.OrigLineNum, origLine)
+			assert.Equal(t, wrappedLine.SegmentInOrig, segment)
+		})
+	}
+
+	assert.Equal(t, len(seq.WrappedLines), li.Len())
+	_, _, ok := li.Locate(li.Len() + 1)
+	assert.False(t, ok)
+	_, _, ok = li.Locate(0)
+	assert.False(t, ok)
+}
+
+// TestLineIndex_SetLine tests that editing an original line produces the
+// same wrapped lines as indexing the edited string from scratch.
+func TestLineIndex_SetLine(t *testing.T) {
+	tests := []struct {
+		origLine int
+		text     string
+		edited   string
+	}{
+		{
+			origLine: 1,
+			text:     "A slow fox",
+			edited: "A slow fox\n\n" +
+				"\tHello world! 🌟 with \x1b[31mcolored\x1b[0m words\r\n" +
+				"Supercalifragilisticexpialidocious\u2028short\nend",
+		},
+		{
+			origLine: 2,
+			text:     "new first\nnew second line",
+			edited: "The quick brown fox jumps over the lazy dog\n" +
+				"new first\nnew second line\n" +
+				"\tHello world! 🌟 with \x1b[31mcolored\x1b[0m words\r\n" +
+				"Supercalifragilisticexpialidocious\u2028short\nend",
+		},
+		{
+			origLine: 6,
+			text:     "the very end of it all",
+			edited: "The quick brown fox jumps over the lazy dog\n\n" +
+				"\tHello world! 🌟 with \x1b[31mcolored\x1b[0m words\r\n" +
+				"Supercalifragilisticexpialidocious\u2028short\nthe very end of it all",
+		},
+	}
+
+	for idx, tt := range tests {
+		t.Run(fmt.Sprintf("Set Line Test %d", idx+1), func(t *testing.T) {
+			li, _ := NewLineIndex(indexTestInput, 10, 4, true, false)
+			li.Len()
+			assert.Nil(t, li.SetLine(tt.origLine, tt.text))
+
+			wrapped, seq, _ := StringWrap(tt.edited, 10, 4, true)
+			lines, wrappedLines, err := li.Lines(1, li.Len())
+			assert.Nil(t, err)
+			assert.Equal(t, strings.Split(wrapped, "\n"), lines)
+			assert.Equal(t, seq.WrappedLines, wrappedLines)
+		})
+	}
+
+	li, _ := NewLineIndex(indexTestInput, 10, 4, true, false)
+	assert.NotNil(t, li.SetLine(0, "out of range"))
+	assert.NotNil(t, li.SetLine(li.OrigLineCount()+1, "out of range"))
+}
+
+// TestLineIndex_SetLineBoundary tests that editing an original line next
+// to a split carriage return and line feed, or before a trailing line of
+// whitespace, produces the same wrapped lines as wrapping the edited
+// string from scratch.
+func TestLineIndex_SetLineBoundary(t *testing.T) {
+	tests := []struct {
+		input    string
+		limit    int
+		origLine int
+		text     string
+		edited   string
+	}{
+		{
+			input:    "日本a\u00a0b\nworld  \t\r\n",
+			limit:    6,
+			origLine: 2,
+			text:     "world  \t\r",
+			edited:   "日本a\u00a0b\nworld  \t\r\r\n",
+		},
+		{
+			input:    "b\r\t\u2028",
+			limit:    4,
+			origLine: 2,
+			text:     "\n",
+			edited:   "b\r\n\u2028",
+		},
+		{
+			input:    "one\rtwo\nthree",
+			limit:    4,
+			origLine: 2,
+			text:     "\nto",
+			edited:   "one\r\nto\nthree",
+		},
+		{
+			input:    "a line\nend",
+			limit:    4,
+			origLine: 2,
+			text:     " \t ",
+			edited:   "a line\n \t ",
+		},
+	}
+
+	for idx, tt := range tests {
+		t.Run(fmt.Sprintf("Set Line Boundary Test %d", idx+1), func(t *testing.T) {
+			li, _ := NewLineIndex(tt.input, tt.limit, 4, true, false)
+			li.Len()
+			assert.Nil(t, li.SetLine(tt.origLine, tt.text))
+
+			_, seq, _ := StringWrap(tt.edited, tt.limit, 4, true)
+			_, wrappedLines, err := li.Lines(1, li.Len())
+			assert.Nil(t, err)
+			assert.Equal(t, len(seq.WrappedLines), li.Len())
+			assert.Equal(t, seq.WrappedLines, wrappedLines)
+		})
+	}
+}
+
+// TestWrapper_NewLineIndex tests that an index created from a wrapper
+// wraps with its measurer and whitespace mode, and that configurations
+// that join original lines are rejected.
+func TestWrapper_NewLineIndex(t *testing.T) {
+	wrappers := []Wrapper{
+		{Limit: 8, TabSize: 4, Metric: MetricRunes},
+		{Limit: 8, TabSize: 4, Measurer: wideHyphen, SplitWord: true},
+		{Limit: 8, TabSize: 4, WhiteSpace: WhiteSpacePreLine},
+		{Limit: 8, TabSize: 4, WhiteSpace: WhiteSpacePreWrap, LeadingMarker: "> "},
+	}
+
+	for idx, w := range wrappers {
+		t.Run(fmt.Sprintf("Wrapper Line Index Test %d", idx+1), func(t *testing.T) {
+			li, err := w.NewLineIndex(indexTestInput)
+			assert.Nil(t, err)
+			assert.Nil(t, li.SetLine(2, "日本語 text   here"))
+
+			edited := strings.Replace(indexTestInput, "\n\n", "\n日本語 text   here\n", 1)
+			_, seq, _ := w.Wrap(edited)
+			_, wrappedLines, err := li.Lines(1, li.Len())
+			assert.Nil(t, err)
+			assert.Equal(t, seq.WrappedLines, wrappedLines)
+		})
+	}
+
+	for _, w := range []Wrapper{
+		{Limit: 8, Reflow: true},
+		{Limit: 8, MaxLines: 2},
+		{Limit: 8, WhiteSpace: WhiteSpaceNormal},
+		{Limit: 8, CarriageReturn: CarriageReturnOverwrite},
+		{Limit: 1},
+	} {
+		_, err := w.NewLineIndex(indexTestInput)
+		assert.NotNil(t, err)
+	}
+}
@@ -0,0 +1,95 @@
+package stringwrap
+
+import "github.com/rivo/uniseg"
+
+// unboundedLimit is a limit wide enough that no line is ever soft wrapped
+const unboundedLimit = int(^uint(0) >> 2)
+
+// minContentWidth returns the width of the widest unit of the token that
+// can never be broken across lines
+func minContentWidth(config wordWrapConfig, token Token) int {
+	switch token.Kind {
+	case TokenWord:
+		// words containing a non-breaking space are never split, and
+		// words made up of a single grapheme cannot be.
+		if !config.splitWord || token.HasNbsp {
+			return token.Width
+		}
+		graphemes := uniseg.NewGraphemes(token.Text)
+		clusters, widest := 0, 0
+		for graphemes.Next() {
+			clusters++
+			widest = max(widest, measureWidth(config.measurer, graphemes.Str()))
+		}
+
+		// when a word is split, a hyphen may follow the last grapheme on
+		// each line.
+		if clusters > 1 {
+			widest += config.hyphenWidth()
+		}
+		return widest
+	case TokenSpace:
+		if !config.trimWhitespace && !config.hangSpaces {
+			return token.Width
+		}
+	case TokenANSI:
+		return token.Width
+	case TokenTab:
+		if !config.trimWhitespace {
+			return config.widestTab()
+		}
+	}
+	return 0
+}
+
+// intrinsicWidths returns the min-content and max-content widths of the
+// tokens passed to yield by the tokens function
+func intrinsicWidths(config wordWrapConfig, tokens func(yield func(Token))) (int, int) {
+	minWidth := 0
+	maxConfig := config
+	maxConfig.limit = unboundedLimit
+
+	stateMachine := newStateMachine(maxConfig, newPositions(1, 1, 0, 0))
+	stateMachine.measurement = &Measurement{}
+	tokens(func(token Token) {
+		minWidth = max(minWidth, minContentWidth(config, token))
+		stateMachine.writeToken(token)
+	})
+	stateMachine.finish()
+
+	// lines that are never wrapped cannot be any narrower, and lines
+	// that are wrapped leave room for the continuation markers.
+	if config.noWrap {
+		minWidth = stateMachine.measurement.MaxWidth
+	} else {
+		minWidth += config.leadingWidth + config.trailingWidth
+	}
+	return minWidth, stateMachine.measurement.MaxWidth
+}
+
+// MinContentWidth returns the intrinsic min-content width of the string:
+// the width of its widest unit that can never be broken across lines.
+// Wrapping the string at this limit (or two, if greater) never produces
+// a line that exceeds the limit.
+//
+// Words are unbreakable units, including words joined by non-breaking
+// spaces. If SplitWord is set, words are instead broken at grapheme
+// boundaries, leaving room for a hyphen. Whitespace only counts when
+// TrimWhitespace is not set. The Limit of the wrapper is ignored.
+func (w Wrapper) MinContentWidth(str string) int {
+	minWidth, _ := intrinsicWidths(w.config(), func(yield func(Token)) {
+		tokenizeFunc(str, w.measurer(), yield)
+	})
+	return minWidth
+}
+
+// MaxContentWidth returns the intrinsic max-content width of the string:
+// the width of its widest line when only hard breaks end a line, with
+// tabs expanded and whitespace trimmed according to the wrapper. The
+// Limit of the wrapper is ignored.
+func (w Wrapper) MaxContentWidth(str string) int {
+	_, maxWidth := intrinsicWidths(w.config(), func(yield func(Token)) {
+		tokenizeFunc(str, w.measurer(), yield)
+	})
+	return maxWidth
+}
@@ -0,0 +1,101 @@
+package stringwrap
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// intrinsicTestCase is a struct that contains the input string, the
+// wrapper configuration, and the expected intrinsic widths.
+type intrinsicTestCase struct {
+	input    string
+	wrapper  Wrapper
+	minWidth int
+	maxWidth int
+}
+
+// TestWrapper_IntrinsicWidths tests the MinContentWidth and MaxContentWidth
+// functions with a variety of test cases.
+func TestWrapper_IntrinsicWidths(t *testing.T) {
+	tests := []intrinsicTestCase{
+		{
+			input:    "The quick brown fox jumps\nover the lazy dog",
+			wrapper:  Wrapper{TabSize: 4, TrimWhitespace: true},
+			minWidth: 5,
+			maxWidth: 25,
+		},
+		{
+			input:    "keep\u00A0together and split",
+			wrapper:  Wrapper{TabSize: 4, TrimWhitespace: true, SplitWord: true},
+			minWidth: 13,
+			maxWidth: 23,
+		},
+		{
+			input:    "Supercalifragilisticexpialidocious 🌟",
+			wrapper:  Wrapper{TabSize: 4, TrimWhitespace: true, SplitWord: true},
+			minWidth: 2,
+			maxWidth: 37,
+		},
+		{
+			input:    "\x1b[31mcolored\x1b[0m text  \n\tindented",
+			wrapper:  Wrapper{TabSize: 4, TrimWhitespace: false},
+			minWidth: 8,
+			maxWidth: 14,
+		},
+		{
+			input:    "\x1b[31mcolored\x1b[0m text  \n\tindented",
+			wrapper:  Wrapper{TabSize: 4, TrimWhitespace: true},
+			minWidth: 8,
+			maxWidth: 12,
+		},
+		{
+			input:    "a\tb",
+			wrapper:  Wrapper{TabSize: 8, TrimWhitespace: false},
+			minWidth: 8,
+			maxWidth: 9,
+		},
+		{
+			input:    "The quick brown fox jumps\nover the lazy dog",
+			wrapper:  Wrapper{TabSize: 4, TrimWhitespace: true, LeadingMarker: "↪ "},
+			minWidth: 7,
+			maxWidth: 25,
+		},
+		{
+			input:    "a\tb",
+			wrapper:  Wrapper{TabSize: 4, TrimWhitespace: false, TabStops: []int{2, 10}},
+			minWidth: 8,
+			maxWidth: 3,
+		},
+		{
+			input:    "",
+			wrapper:  Wrapper{TabSize: 4},
+			minWidth: 0,
+			maxWidth: 0,
+		},
+	}
+
+	for idx, tt := range tests {
+		t.Run(fmt.Sprintf("Intrinsic Widths Test %d", idx+1), func(t *testing.T) {
+			minWidth := tt.wrapper.MinContentWidth(tt.input)
+			maxWidth := tt.wrapper.MaxContentWidth(tt.input)
+			assert.Equal(t, tt.minWidth, minWidth)
+			assert.Equal(t, tt.maxWidth, maxWidth)
+
+			// wrapping at the min-content width never overflows, and the
+			// widest line wrapped at the max-content width fills it.
+			wrapper := tt.wrapper
+			wrapper.Limit = max(minWidth, 2)
+			_, seq, _ := wrapper.Wrap(tt.input)
+			for _, wrappedLine := range seq.WrappedLines {
+				assert.False(t, wrappedLine.NotWithinLimit)
+			}
+
+			wrapper.Limit = max(maxWidth, 2)
+			measurement, err := wrapper.Measure(tt.input, false)
+			assert.Nil(t, err)
+			assert.Equal(t, maxWidth, measurement.MaxWidth)
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package stringwrap
+
+// Measurement holds the dimensions of a string once wrapped.
+type Measurement struct {
+	// Lines is the number of wrapped lines.
+	Lines int
+	// MaxWidth is the viewable width of the widest wrapped line.
+	MaxWidth int
+	// Widths is the viewable width of each wrapped line, which is only
+	// recorded when requested.
+	Widths []int
+}
+
+// measureTokens runs the wrapping state machine over the tokens passed
+// to yield by the tokens function, recording the dimensions of each line
+// without building any output
+func measureTokens(
+	config wordWrapConfig, lineWidths bool, tokens func(yield func(Token)),
+) Measurement {
+	return *measureState(config, lineWidths, tokens).measurement
+}
+
+// measureState runs the wrapping state machine over the tokens like
+// measureTokens, returning the finished state machine
+func measureState(
+	config wordWrapConfig, lineWidths bool, tokens func(yield func(Token)),
+) *wrapStateMachine {
+	stateMachine := newStateMachine(config, newPositions(1, 1, 0, 0))
+	stateMachine.measurement = &Measurement{}
+	stateMachine.lineWidths = lineWidths
+	tokens(stateMachine.writeToken)
+	stateMachine.finish()
+	return stateMachine
+}
+
+// Measure runs the wrapping algorithm over the input string without
+// building the wrapped output, returning the number of lines and the
+// width of the widest line that Wrap would produce. If lineWidths is
+// true, the width of every wrapped line is recorded as well.
+//
+// The string is tokenized as it is wrapped and only the current line and
+// word are buffered, so no allocations proportional to the size of the
+// input are made (other than the optional line widths).
+func (w Wrapper) Measure(str string, lineWidths bool) (Measurement, error) {
+	config := w.config()
+	if err := config.validate(); err != nil {
+		return Measurement{}, err
+	}
+	if w.Reflow {
+		stateMachine := newStateMachine(config, newPositions(1, 1, 0, 0))
+		stateMachine.measurement = &Measurement{}
+		stateMachine.lineWidths = lineWidths
+		reflowTokens(stateMachine, tokenize(str, config.measurer))
+		stateMachine.finish()
+		return *stateMachine.measurement, nil
+	}
+	return measureTokens(config, lineWidths, func(yield func(Token)) {
+		tokenizeFunc(str, config.measurer, yield)
+	}), nil
+}
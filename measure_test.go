@@ -0,0 +1,73 @@
+package stringwrap
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestWrapper_Measure tests that measuring a string matches the dimensions
+// of the lines produced by wrapping it.
+func TestWrapper_Measure(t *testing.T) {
+	inputs := []string{
+		"The quick brown fox jumps over the lazy dog",
+		"Supercalifragilisticexpialidocious is a long word\n\twith 🌟stars  ",
+		"\x1b[32m\tGreen 🍀 text with ANSI and emojis\x1b[0m alongside  plain",
+		"foo\u2028barbazbaz qux\vness\u00A0here\n\n",
+		"",
+	}
+
+	for idx, input := range inputs {
+		for _, limit := range []int{2, 5, 10, 25} {
+			for _, trim := range []bool{true, false} {
+				name := fmt.Sprintf("Measure Test %d/%d/%t", idx+1, limit, trim)
+				t.Run(name, func(t *testing.T) {
+					wrapper := Wrapper{Limit: limit, TabSize: 4, TrimWhitespace: trim, SplitWord: true}
+					_, seq, _ := wrapper.Wrap(input)
+
+					var widths []int
+					maxWidth := 0
+					for _, wrappedLine := range seq.WrappedLines {
+						widths = append(widths, wrappedLine.Width)
+						maxWidth = max(maxWidth, wrappedLine.Width)
+					}
+
+					measurement, err := wrapper.Measure(input, true)
+					assert.Nil(t, err)
+					assert.Equal(t, Measurement{
+						Lines:    len(seq.WrappedLines),
+						MaxWidth: maxWidth,
+						Widths:   widths,
+					}, measurement)
+
+					measurement, err = wrapper.Measure(input, false)
+					assert.Nil(t, err)
+					assert.Nil(t, measurement.Widths)
+				})
+			}
+		}
+	}
+
+	_, err := Wrapper{Limit: 1}.Measure("text", false)
+	assert.NotNil(t, err)
+}
+
+// TestWrapper_MeasureAllocs tests that the allocations made by Measure do
+// not grow with the size of the input.
+func TestWrapper_MeasureAllocs(t *testing.T) {
+	wrapper := Wrapper{Limit: 20, TabSize: 4, TrimWhitespace: true}
+	paragraph := "The quick brown \x1b[31mfox\x1b[0m jumps over the lazy 🌟 dog\n\t"
+
+	small := strings.Repeat(paragraph, 10)
+	large := strings.Repeat(paragraph, 10000)
+
+	smallAllocs := testing.AllocsPerRun(10, func() {
+		_, _ = wrapper.Measure(small, false)
+	})
+	largeAllocs := testing.AllocsPerRun(10, func() {
+		_, _ = wrapper.Measure(large, false)
+	})
+	assert.Equal(t, smallAllocs, largeAllocs)
+}
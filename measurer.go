@@ -0,0 +1,29 @@
+package stringwrap
+
+import "github.com/mattn/go-runewidth"
+
+// Measurer measures the viewable width of text, such as the number of
+// terminal columns it occupies. It is used for every width calculation
+// made while wrapping, so that the wrapped lines agree with how the text
+// is actually rendered.
+type Measurer interface {
+	// StringWidth returns the viewable width of the string, which is
+	// always a single grapheme cluster or whitespace character.
+	StringWidth(str string) int
+}
+
+// WidthFunc is an adapter that allows an ordinary function, such as
+// uniseg.StringWidth, to be used as a Measurer.
+type WidthFunc func(str string) int
+
+// StringWidth calls f(str).
+func (f WidthFunc) StringWidth(str string) int { return f(str) }
+
+// measureWidth returns the viewable width of the string using the
+// measurer, falling back to go-runewidth if there is none
+func measureWidth(m Measurer, str string) int {
+	if m == nil {
+		return runewidth.StringWidth(str)
+	}
+	return m.StringWidth(str)
+}
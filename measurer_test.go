@@ -0,0 +1,120 @@
+package stringwrap
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/mattn/go-runewidth"
+	"github.com/stretchr/testify/assert"
+)
+
+// nerdFontWidth measures private-use glyphs, such as Nerd Font icons, as
+// two columns wide.
+func nerdFontWidth(str string) int {
+	for _, r := range str {
+		if r >= '\uE000' && r <= '\uF8FF' {
+			return 2
+		}
+	}
+	return runewidth.StringWidth(str)
+}
+
+// doubleWidth measures everything as twice as wide as go-runewidth.
+func doubleWidth(str string) int { return 2 * runewidth.StringWidth(str) }
+
+// measurerTestCase is a struct that contains the input string, the
+// wrapper configuration, and the expected wrapped string and widths.
+type measurerTestCase struct {
+	input    string
+	wrapper  Wrapper
+	expected string
+	widths   []int
+}
+
+// TestWrapper_Measurer tests that a custom Measurer is used for every
+// width calculation with a variety of test cases.
+func TestWrapper_Measurer(t *testing.T) {
+	tests := []measurerTestCase{
+		{
+			input: "\uF115 src \uF115 docs \uF115 tests",
+			wrapper: Wrapper{
+				Limit: 12, TabSize: 4, TrimWhitespace: true, Measurer: WidthFunc(nerdFontWidth),
+			},
+			expected: "\uF115 src \uF115\ndocs \uF115\ntests",
+			widths:   []int{9, 7, 5},
+		},
+		{
+			input: "\uF115 src \uF115 docs \uF115 tests",
+			wrapper: Wrapper{
+				Limit: 12, TabSize: 4, TrimWhitespace: true,
+			},
+			expected: "\uF115 src \uF115 docs\n\uF115 tests",
+			widths:   []int{12, 7},
+		},
+		{
+			input: "ab cd ef",
+			wrapper: Wrapper{
+				Limit: 10, TabSize: 4, TrimWhitespace: true, Measurer: WidthFunc(doubleWidth),
+			},
+			expected: "ab cd\nef",
+			widths:   []int{10, 4},
+		},
+		{
+			input: "\tab\tc",
+			wrapper: Wrapper{
+				Limit: 20, TabSize: 4, TrimWhitespace: false, Measurer: WidthFunc(doubleWidth),
+			},
+			expected: "    ab    c",
+			widths:   []int{14},
+		},
+		{
+			input: "ab    \x1b[1mcd\x1b[0m",
+			wrapper: Wrapper{
+				Limit: 10, TabSize: 4, TrimWhitespace: true, Measurer: WidthFunc(doubleWidth),
+			},
+			expected: "ab\n\x1b[1mcd\x1b[0m",
+			widths:   []int{4, 4},
+		},
+		{
+			input: "abcdefgh",
+			wrapper: Wrapper{
+				Limit: 8, TabSize: 4, TrimWhitespace: true, SplitWord: true,
+				Measurer: WidthFunc(doubleWidth),
+			},
+			expected: "abc-\ndef-\ngh",
+			widths:   []int{8, 8, 4},
+		},
+		{
+			input: "yo\u00A0u go",
+			wrapper: Wrapper{
+				Limit: 9, TabSize: 4, TrimWhitespace: true, Measurer: WidthFunc(doubleWidth),
+			},
+			expected: "yo\u00A0u\ngo",
+			widths:   []int{8, 4},
+		},
+	}
+
+	for idx, tt := range tests {
+		t.Run(fmt.Sprintf("Measurer Test %d", idx+1), func(t *testing.T) {
+			wrapped, seq, err := tt.wrapper.Wrap(tt.input)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.expected, wrapped)
+
+			widths := make([]int, 0, len(seq.WrappedLines))
+			for _, wrappedLine := range seq.WrappedLines {
+				widths = append(widths, wrappedLine.Width)
+			}
+			assert.Equal(t, tt.widths, widths)
+
+			// every other path measures with the same measurer.
+			tokenWrapped, tokenSeq, err := tt.wrapper.WrapTokens(tt.wrapper.Tokenize(tt.input))
+			assert.Nil(t, err)
+			assert.Equal(t, wrapped, tokenWrapped)
+			assert.Equal(t, seq, tokenSeq)
+
+			measurement, err := tt.wrapper.Measure(tt.input, true)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.widths, measurement.Widths)
+		})
+	}
+}
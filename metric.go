@@ -0,0 +1,68 @@
+package stringwrap
+
+import "unicode/utf8"
+
+// Metric is the unit that the limit of a Wrapper and the width of each
+// wrapped line are measured in.
+type Metric int
+
+const (
+	// MetricWidth measures the viewable width of the text, using the
+	// Measurer of the wrapper.
+	MetricWidth Metric = iota
+	// MetricBytes measures the number of bytes of the UTF-8 encoded
+	// text, such as for database columns capped in bytes.
+	MetricBytes
+	// MetricRunes measures the number of Unicode code points.
+	MetricRunes
+	// MetricGraphemes measures the number of grapheme clusters, or
+	// user-perceived characters, as counted by most chat services.
+	MetricGraphemes
+	// MetricUTF16 measures the number of UTF-16 code units, as counted
+	// by JavaScript strings and language server positions.
+	MetricUTF16
+)
+
+// byteLength returns the number of bytes in the string
+func byteLength(str string) int { return len(str) }
+
+// graphemeLength returns the number of grapheme clusters in the string,
+// which is always one as the string is a single cluster or whitespace
+// character
+func graphemeLength(str string) int {
+	if str == "" {
+		return 0
+	}
+	return 1
+}
+
+// utf16Length returns the number of UTF-16 code units in the string
+func utf16Length(str string) int {
+	length := 0
+	for _, r := range str {
+		// runes outside the basic multilingual plane are encoded as a
+		// surrogate pair.
+		if r >= 0x10000 {
+			length += 2
+		} else {
+			length += 1
+		}
+	}
+	return length
+}
+
+// measurer returns the Measurer that measures in the metric, or nil if
+// the metric is the viewable width
+func (m Metric) measurer() Measurer {
+	switch m {
+	case MetricBytes:
+		return WidthFunc(byteLength)
+	case MetricRunes:
+		return WidthFunc(utf8.RuneCountInString)
+	case MetricGraphemes:
+		return WidthFunc(graphemeLength)
+	case MetricUTF16:
+		return WidthFunc(utf16Length)
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package stringwrap
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// metricTestCase is a struct that contains the wrapper configuration,
+// and the expected wrapped string and widths in the metric.
+type metricTestCase struct {
+	wrapper  Wrapper
+	expected string
+	widths   []int
+}
+
+// TestWrapper_Metric tests wrapping with each length metric with a
+// variety of test cases.
+func TestWrapper_Metric(t *testing.T) {
+	input := "héllo wörld 日本語 👍🏽 e\u0301e\u0301 \x1b[31mred\x1b[0m"
+	tests := []metricTestCase{
+		{
+			wrapper:  Wrapper{Limit: 8, TabSize: 4, TrimWhitespace: true, Metric: MetricBytes},
+			expected: "héllo\nwörld\n日本語\n👍🏽\ne\u0301e\u0301 \x1b[31m\nred\x1b[0m",
+			widths:   []int{6, 6, 9, 8, 7, 3},
+		},
+		{
+			wrapper:  Wrapper{Limit: 8, TabSize: 4, TrimWhitespace: true, SplitWord: true, Metric: MetricBytes},
+			expected: "héllo\nwörld\n日本-\n語\n👍🏽\ne\u0301e\u0301 \x1b[31m\nred\x1b[0m",
+			widths:   []int{6, 6, 7, 3, 8, 7, 3},
+		},
+		{
+			wrapper:  Wrapper{Limit: 8, TabSize: 4, TrimWhitespace: true, Metric: MetricRunes},
+			expected: "héllo\nwörld\n日本語 👍🏽\ne\u0301e\u0301 \x1b[31mred\x1b[0m",
+			widths:   []int{5, 5, 6, 8},
+		},
+		{
+			wrapper:  Wrapper{Limit: 8, TabSize: 4, TrimWhitespace: true, SplitWord: true, Metric: MetricRunes},
+			expected: "héllo w-\nörld 日本語\n👍🏽 e\u0301e\u0301 \x1b[31m\nred\x1b[0m",
+			widths:   []int{8, 8, 8, 3},
+		},
+		{
+			wrapper:  Wrapper{Limit: 8, TabSize: 4, TrimWhitespace: true, Metric: MetricGraphemes},
+			expected: "héllo\nwörld\n日本語 👍🏽 e\u0301e\u0301\n\x1b[31mred\x1b[0m",
+			widths:   []int{5, 5, 8, 3},
+		},
+		{
+			wrapper:  Wrapper{Limit: 8, TabSize: 4, TrimWhitespace: true, SplitWord: true, Metric: MetricGraphemes},
+			expected: "héllo w-\nörld 日本語\n👍🏽 e\u0301e\u0301 \x1b[31mred\x1b[0m",
+			widths:   []int{8, 8, 8},
+		},
+		{
+			wrapper:  Wrapper{Limit: 8, TabSize: 4, TrimWhitespace: true, Metric: MetricUTF16},
+			expected: "héllo\nwörld\n日本語 👍🏽\ne\u0301e\u0301 \x1b[31mred\x1b[0m",
+			widths:   []int{5, 5, 8, 8},
+		},
+		{
+			wrapper:  Wrapper{Limit: 8, TabSize: 4, TrimWhitespace: true, SplitWord: true, Metric: MetricUTF16},
+			expected: "héllo w-\nörld 日本語\n👍🏽 e\u0301-\ne\u0301 \x1b[31mred\x1b[0m",
+			widths:   []int{8, 8, 8, 6},
+		},
+		{
+			wrapper: Wrapper{
+				Limit: 8, TabSize: 4, TrimWhitespace: true, Metric: MetricWidth,
+				Measurer: WidthFunc(doubleWidth),
+			},
+			expected: "héllo\nwörld\n日本語\n👍🏽\ne\u0301e\u0301 \x1b[31m\nred\x1b[0m",
+			widths:   []int{10, 10, 12, 4, 6, 6},
+		},
+	}
+
+	for idx, tt := range tests {
+		t.Run(fmt.Sprintf("Metric Test %d", idx+1), func(t *testing.T) {
+			wrapped, seq, err := tt.wrapper.Wrap(input)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.expected, wrapped)
+
+			widths := make([]int, 0, len(seq.WrappedLines))
+			for _, wrappedLine := range seq.WrappedLines {
+				widths = append(widths, wrappedLine.Width)
+			}
+			assert.Equal(t, tt.widths, widths)
+
+			measurement, err := tt.wrapper.Measure(input, true)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.widths, measurement.Widths)
+		})
+	}
+}
+
+// TestMetric_Measurer tests the length of strings in each metric.
+func TestMetric_Measurer(t *testing.T) {
+	tests := []struct {
+		str      string
+		expected []int
+	}{
+		{str: "a", expected: []int{1, 1, 1, 1}},
+		{str: "é", expected: []int{2, 1, 1, 1}},
+		{str: "e\u0301", expected: []int{3, 2, 1, 2}},
+		{str: "日", expected: []int{3, 1, 1, 1}},
+		{str: "👍🏽", expected: []int{8, 2, 1, 4}},
+		{str: "\u00A0", expected: []int{2, 1, 1, 1}},
+	}
+
+	metrics := []Metric{MetricBytes, MetricRunes, MetricGraphemes, MetricUTF16}
+	for idx, tt := range tests {
+		t.Run(fmt.Sprintf("Metric Measurer Test %d", idx+1), func(t *testing.T) {
+			for metricIdx, metric := range metrics {
+				assert.Equal(t, tt.expected[metricIdx], metric.measurer().StringWidth(tt.str))
+			}
+		})
+	}
+	assert.Nil(t, MetricWidth.measurer())
+}
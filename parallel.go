@@ -0,0 +1,134 @@
+package stringwrap
+
+import (
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/galactixx/ansiwalker"
+)
+
+// parallelChunkSize is the minimum size in bytes of each chunk of a
+// string that is wrapped concurrently. Smaller strings are not worth the
+// overhead of spreading across goroutines.
+var parallelChunkSize = 64 * 1024
+
+// wrappedChunk holds the result of wrapping a single chunk of a string
+type wrappedChunk struct {
+	wrapped   string
+	seq       *WrappedStringSeq
+	runeCount int
+}
+
+// splitChunks splits the string at hard breaks into chunks of at least
+// the given size, keeping each hard break at the end of its chunk. Lone
+// carriage returns are never split at, as they may not end the line.
+func splitChunks(str string, size int) []string {
+	var chunks []string
+	start := 0
+	idx := 0
+	for idx < len(str) {
+		r, _, next, _ := ansiwalker.ANSIWalk(str, idx)
+		if next < 0 {
+			break
+		}
+		idx = next
+		if idx-start >= size && isHardBreak(r) && r != '\r' {
+			chunks = append(chunks, str[start:idx])
+			start = idx
+		}
+	}
+	if start < len(str) {
+		chunks = append(chunks, str[start:])
+	}
+	return chunks
+}
+
+// wrapParallel splits the string into chunks of whole original lines,
+// wraps the chunks concurrently, and stitches the results together.
+//
+// Hard breaks fully reset the state of the wrapping algorithm, so each
+// chunk wraps exactly as it would within the whole string, and only its
+// line numbers and offsets need to be shifted.
+func wrapParallel(str string, config wordWrapConfig, workers int) (
+	string, *WrappedStringSeq, error,
+) {
+	if err := config.validate(); err != nil {
+		return "", nil, err
+	}
+
+	// aim for a few chunks per worker so that uneven chunks still keep
+	// every worker busy.
+	chunks := splitChunks(str, max(len(str)/(workers*4), parallelChunkSize))
+	results := make([]wrappedChunk, len(chunks))
+
+	var wg sync.WaitGroup
+	jobs := make(chan int)
+	for w := 0; w < min(workers, len(chunks)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				wrapped, seq, _ := wrapTokensFrom(
+					tokenize(chunks[i], config.measurer), config, newPositions(1, 1, 0, 0),
+				)
+				results[i] = wrappedChunk{
+					wrapped:   wrapped,
+					seq:       seq,
+					runeCount: utf8.RuneCountInString(chunks[i]),
+				}
+			}
+		}()
+	}
+	for i := range chunks {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	// stitch the chunks together, shifting the line numbers and offsets
+	// of each chunk by everything that came before it.
+	wrappedStringSeq := WrappedStringSeq{
+		WordSplitAllowed:   config.splitWord,
+		TabSize:            config.tabSize,
+		TabStops:           config.tabStops,
+		KeepTabs:           config.keepTabs,
+		TrimWhitespace:     config.trimWhitespace,
+		Limit:              config.limit,
+		LineBreak:          config.lineBreak,
+		SoftBreak:          config.softBreak,
+		PreserveSeparators: config.preserveSeparators,
+		LeadingMarker:      config.leadingMarker,
+		TrailingMarker:     config.trailingMarker,
+	}
+	var buffer strings.Builder
+	curLineNum, origLineNum, byteIdx, runeIdx := 0, 0, 0, 0
+	for i, result := range results {
+		wrappedIdx := buffer.Len()
+		buffer.WriteString(result.wrapped)
+		for _, wrappedLine := range result.seq.WrappedLines {
+			wrappedLine.CurLineNum += curLineNum
+			wrappedLine.OrigLineNum += origLineNum
+			wrappedLine.OrigByteOffset.Start += byteIdx
+			wrappedLine.OrigByteOffset.End += byteIdx
+			wrappedLine.OrigRuneOffset.Start += runeIdx
+			wrappedLine.OrigRuneOffset.End += runeIdx
+			wrappedLine.WrappedByteOffset.Start += wrappedIdx
+			wrappedLine.WrappedByteOffset.End += wrappedIdx
+			wrappedStringSeq.appendWrappedSeq(wrappedLine)
+		}
+		if len(result.seq.WrappedLines) > 0 {
+			curLineNum += len(result.seq.WrappedLines)
+			origLineNum += result.seq.lastWrappedLine().OrigLineNum
+		} else if len(wrappedStringSeq.WrappedLines) > 0 {
+			// a chunk that wraps to no lines, such as trimmed trailing
+			// whitespace, is still spanned by the line before it.
+			lastWrappedLine := wrappedStringSeq.lastWrappedLine()
+			lastWrappedLine.OrigByteOffset.End += len(chunks[i])
+			lastWrappedLine.OrigRuneOffset.End += result.runeCount
+		}
+		byteIdx += len(chunks[i])
+		runeIdx += result.runeCount
+	}
+	return buffer.String(), &wrappedStringSeq, nil
+}
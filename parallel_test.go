@@ -0,0 +1,85 @@
+package stringwrap
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSplitChunks tests that strings are only split at hard breaks, and
+// that the chunks cover the whole string.
+func TestSplitChunks(t *testing.T) {
+	input := "first line\nsecond\x1b]0;title\ncontinued\x07 line\r\nthird\u2028fourth"
+	chunks := splitChunks(input, 5)
+	assert.Equal(
+		t,
+		[]string{
+			"first line\n",
+			"second\x1b]0;title\ncontinued\x07 line\r\n",
+			"third\u2028",
+			"fourth",
+		},
+		chunks,
+	)
+	assert.Equal(t, input, strings.Join(chunks, ""))
+}
+
+// TestWrapper_WrapParallel tests that wrapping concurrently produces output
+// and metadata identical to wrapping sequentially.
+func TestWrapper_WrapParallel(t *testing.T) {
+	defer func(size int) { parallelChunkSize = size }(parallelChunkSize)
+	parallelChunkSize = 16
+
+	paragraphs := []string{
+		"The quick brown fox jumps over the lazy dog",
+		"",
+		"\tHello world! 🌟 with \x1b[31mcolored\x1b[0m words",
+		"Supercalifragilisticexpialidocious short",
+		"éclair and café",
+	}
+	var builder strings.Builder
+	for i := 0; i < 20; i++ {
+		builder.WriteString(paragraphs[i%len(paragraphs)])
+		builder.WriteString("\n")
+	}
+	inputs := []string{
+		builder.String(),
+		strings.TrimSuffix(builder.String(), "\n"),
+		"",
+		"no hard breaks at all in this string",
+	}
+
+	for idx, input := range inputs {
+		for _, workers := range []int{2, 3, 8} {
+			for _, splitWord := range []bool{false, true} {
+				name := fmt.Sprintf("Parallel Test %d/%d/%t", idx+1, workers, splitWord)
+				t.Run(name, func(t *testing.T) {
+					wrapper := Wrapper{Limit: 12, TabSize: 4, TrimWhitespace: true, SplitWord: splitWord}
+					expected, expectedSeq, _ := wrapper.Wrap(input)
+
+					wrapper.Workers = workers
+					wrapped, seq, err := wrapper.Wrap(input)
+					assert.Nil(t, err)
+					assert.Equal(t, expected, wrapped)
+					assert.Equal(t, expectedSeq, seq)
+				})
+			}
+		}
+	}
+
+	// a chunk boundary directly before trailing whitespace leaves a final
+	// chunk that wraps to no lines.
+	input := strings.Repeat("abcdefghijklmno\n", 4) + "   "
+	wrapper := Wrapper{Limit: 10, TabSize: 4, TrimWhitespace: true}
+	expected, expectedSeq, _ := wrapper.Wrap(input)
+	wrapper.Workers = 2
+	wrapped, seq, err := wrapper.Wrap(input)
+	assert.Nil(t, err)
+	assert.Equal(t, expected, wrapped)
+	assert.Equal(t, expectedSeq, seq)
+
+	_, _, err = Wrapper{Limit: 1, Workers: 4}.Wrap("text")
+	assert.NotNil(t, err)
+}
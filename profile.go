@@ -0,0 +1,74 @@
+package stringwrap
+
+import (
+	"fmt"
+
+	"github.com/mattn/go-runewidth"
+	"github.com/rivo/uniseg"
+)
+
+// TerminalProfile names a set of width rules that match how a family of
+// terminal emulators renders grapheme clusters.
+type TerminalProfile string
+
+const (
+	// ProfileGraphemeAware measures each grapheme cluster as a whole, as
+	// terminals supporting grapheme clustering (mode 2027) do. ZWJ
+	// sequences, flags, skin tones and VS16 emoji are two columns wide.
+	ProfileGraphemeAware TerminalProfile = "grapheme-aware"
+	// ProfileWcwidth measures each code point of a grapheme cluster with
+	// wcwidth and adds them up, so ZWJ sequences are as wide as the emoji
+	// they join and skin tone modifiers add their own width.
+	ProfileWcwidth TerminalProfile = "wcwidth-per-codepoint"
+	// ProfileLegacyXterm measures each code point like ProfileWcwidth, but
+	// with the tables of older terminals, where emoji and other
+	// pictographs are one column wide.
+	ProfileLegacyXterm TerminalProfile = "legacy-xterm"
+)
+
+// codepointMeasurer measures a grapheme cluster as the sum of the widths
+// of its code points
+type codepointMeasurer struct {
+	condition *runewidth.Condition
+	legacy    bool
+}
+
+// StringWidth returns the viewable width of the grapheme cluster.
+func (m codepointMeasurer) StringWidth(cluster string) int {
+	width := 0
+	for _, r := range cluster {
+		// variation selectors only change how the character before them
+		// is presented, which these terminals ignore.
+		if r >= 0xFE00 && r <= 0xFE0F {
+			continue
+		}
+		w := m.condition.RuneWidth(r)
+		if m.legacy && w > 1 && isPictograph(r) {
+			w = 1
+		}
+		width += w
+	}
+	return width
+}
+
+// ProfileMeasurer returns a Measurer that measures grapheme clusters
+// according to the named terminal profile. Text wrapped with it lines
+// up with what terminals of that family actually render.
+//
+// Returns an error if the profile is not one of the built-in profiles.
+func ProfileMeasurer(profile TerminalProfile) (Measurer, error) {
+	switch profile {
+	case ProfileGraphemeAware:
+		return WidthFunc(uniseg.StringWidth), nil
+	case ProfileWcwidth:
+		return codepointMeasurer{
+			condition: &runewidth.Condition{StrictEmojiNeutral: true},
+		}, nil
+	case ProfileLegacyXterm:
+		return codepointMeasurer{
+			condition: &runewidth.Condition{StrictEmojiNeutral: true},
+			legacy:    true,
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown terminal profile %q", profile)
+}
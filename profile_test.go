@@ -0,0 +1,82 @@
+package stringwrap
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// profileTestCase is a struct that contains a grapheme cluster and its
+// expected width in each terminal profile.
+type profileTestCase struct {
+	cluster       string
+	graphemeAware int
+	wcwidth       int
+	legacyXterm   int
+}
+
+// TestProfileMeasurer tests the widths measured by each terminal profile
+// with a variety of test cases.
+func TestProfileMeasurer(t *testing.T) {
+	tests := []profileTestCase{
+		{cluster: "a", graphemeAware: 1, wcwidth: 1, legacyXterm: 1},
+		{cluster: "é", graphemeAware: 1, wcwidth: 1, legacyXterm: 1},
+		{cluster: "日", graphemeAware: 2, wcwidth: 2, legacyXterm: 2},
+		{cluster: "🌟", graphemeAware: 2, wcwidth: 2, legacyXterm: 1},
+		{cluster: "👍🏽", graphemeAware: 2, wcwidth: 4, legacyXterm: 2},
+		{cluster: "👩\u200D👩\u200D👧", graphemeAware: 2, wcwidth: 6, legacyXterm: 3},
+		{cluster: "🇯🇵", graphemeAware: 2, wcwidth: 2, legacyXterm: 2},
+		{cluster: "❤\uFE0F", graphemeAware: 2, wcwidth: 1, legacyXterm: 1},
+	}
+
+	graphemeAware, err := ProfileMeasurer(ProfileGraphemeAware)
+	assert.Nil(t, err)
+	wcwidth, err := ProfileMeasurer(ProfileWcwidth)
+	assert.Nil(t, err)
+	legacyXterm, err := ProfileMeasurer(ProfileLegacyXterm)
+	assert.Nil(t, err)
+
+	for idx, tt := range tests {
+		t.Run(fmt.Sprintf("Profile Measurer Test %d", idx+1), func(t *testing.T) {
+			assert.Equal(t, tt.graphemeAware, graphemeAware.StringWidth(tt.cluster))
+			assert.Equal(t, tt.wcwidth, wcwidth.StringWidth(tt.cluster))
+			assert.Equal(t, tt.legacyXterm, legacyXterm.StringWidth(tt.cluster))
+		})
+	}
+
+	_, err = ProfileMeasurer("unknown")
+	assert.NotNil(t, err)
+}
+
+// TestProfileMeasurer_Wrap tests that wrapping with a terminal profile
+// never splits grapheme clusters while measuring them per profile.
+func TestProfileMeasurer_Wrap(t *testing.T) {
+	input := "team 👩\u200D👩\u200D👧 ok"
+	tests := []struct {
+		profile  TerminalProfile
+		expected string
+		widths   []int
+	}{
+		{profile: ProfileGraphemeAware, expected: "team 👩\u200D👩\u200D👧\nok", widths: []int{7, 2}},
+		{profile: ProfileWcwidth, expected: "team\n👩\u200D👩\u200D👧 ok", widths: []int{4, 9}},
+		{profile: ProfileLegacyXterm, expected: "team 👩\u200D👩\u200D👧\nok", widths: []int{8, 2}},
+	}
+
+	for idx, tt := range tests {
+		t.Run(fmt.Sprintf("Profile Wrap Test %d", idx+1), func(t *testing.T) {
+			measurer, err := ProfileMeasurer(tt.profile)
+			assert.Nil(t, err)
+			wrapper := Wrapper{Limit: 9, TabSize: 4, TrimWhitespace: true, Measurer: measurer}
+			wrapped, seq, err := wrapper.Wrap(input)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.expected, wrapped)
+
+			widths := make([]int, 0, len(seq.WrappedLines))
+			for _, wrappedLine := range seq.WrappedLines {
+				widths = append(widths, wrappedLine.Width)
+			}
+			assert.Equal(t, tt.widths, widths)
+		})
+	}
+}
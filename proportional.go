@@ -0,0 +1,166 @@
+package stringwrap
+
+import (
+	"errors"
+	"math"
+	"unicode/utf8"
+)
+
+// advanceScale is the number of fixed-point units per font unit that
+// advances are rounded to, so that proportional text is wrapped by the
+// same integer state machine as terminal text
+const advanceScale = 1 << 16
+
+// AdvanceMeasurer measures the advance width of text set in a
+// proportional font, in font units such as points or pixels.
+type AdvanceMeasurer interface {
+	// Advance returns the advance width of the string, which is always
+	// a single grapheme cluster or whitespace character.
+	Advance(str string) float64
+}
+
+// AdvanceFunc is an adapter that allows an ordinary function, such as one
+// wrapping font.MeasureString from golang.org/x/image/font, to be used as
+// an AdvanceMeasurer.
+type AdvanceFunc func(str string) float64
+
+// Advance calls f(str).
+func (f AdvanceFunc) Advance(str string) float64 { return f(str) }
+
+// AdvanceTable measures each grapheme cluster by the advance of its first
+// rune, as listed in a table of glyph advances. Combining marks and other
+// runes after the first are drawn over it and add no advance.
+type AdvanceTable struct {
+	// Advances maps runes to the advance width of their glyph.
+	Advances map[rune]float64
+	// Default is the advance width of runes missing from Advances.
+	Default float64
+}
+
+// Advance returns the advance width of the first rune of the cluster.
+func (t AdvanceTable) Advance(cluster string) float64 {
+	r, _ := utf8.DecodeRuneInString(cluster)
+	if advance, ok := t.Advances[r]; ok {
+		return advance
+	}
+	return t.Default
+}
+
+// toAdvanceUnits converts an advance width to fixed-point units
+func toAdvanceUnits(advance float64) int {
+	return int(math.Round(advance * advanceScale))
+}
+
+// fromAdvanceUnits converts fixed-point units back to an advance width
+func fromAdvanceUnits(units int) float64 {
+	return float64(units) / advanceScale
+}
+
+// advanceMeasurer adapts an AdvanceMeasurer to the Measurer used by the
+// wrapping state machine, measuring in fixed-point units
+type advanceMeasurer struct {
+	advances     AdvanceMeasurer
+	spaceAdvance float64
+}
+
+// StringWidth returns the advance width of the string in fixed-point
+// units, using the fixed space advance for spaces if there is one.
+func (m advanceMeasurer) StringWidth(str string) int {
+	if m.spaceAdvance > 0 && (str == " " || str == "\u00A0") {
+		return toAdvanceUnits(m.spaceAdvance)
+	}
+	return toAdvanceUnits(m.advances.Advance(str))
+}
+
+// ProportionalWrapper holds the configuration used to wrap text set in a
+// proportional font, such as text drawn to a PDF or an image, where
+// widths are fractional advances rather than terminal columns.
+//
+// Breaking, word splitting, hyphenation and the metadata of each line
+// are exactly the same as for Wrapper. Advances are rounded to 1/65536 of
+// a font unit while wrapping.
+type ProportionalWrapper struct {
+	// Limit is the maximum advance width allowed per line.
+	Limit float64
+	// TabSize defines how many space advances a tab stop is apart.
+	TabSize int
+	// TrimWhitespace strips leading and trailing whitespace from each
+	// wrapped line.
+	TrimWhitespace bool
+	// SplitWord allows words to be split across lines if they exceed
+	// the limit.
+	SplitWord bool
+	// Advances measures the advance width of grapheme clusters,
+	// whitespace and hyphens.
+	Advances AdvanceMeasurer
+	// SpaceAdvance is the advance width of spaces and non-breaking
+	// spaces, regardless of the text around them. Zero measures them
+	// with Advances.
+	SpaceAdvance float64
+}
+
+// ProportionalSeq holds the sequence of lines wrapped by a
+// ProportionalWrapper, along with their advance widths.
+type ProportionalSeq struct {
+	// WrappedStringSeq holds the metadata of each wrapped line. The
+	// width of each line is its advance width rounded up to a whole font
+	// unit, and the limit is rounded down.
+	*WrappedStringSeq
+	// Advances is the advance width of each wrapped line.
+	Advances []float64
+	// AdvanceLimit is the maximum advance width allowed per line.
+	AdvanceLimit float64
+}
+
+// config converts the wrapper into the internal configuration
+func (w ProportionalWrapper) config() wordWrapConfig {
+	measurer := advanceMeasurer{advances: w.Advances, spaceAdvance: w.SpaceAdvance}
+	return wordWrapConfig{
+		limit:          toAdvanceUnits(w.Limit),
+		tabSize:        w.TabSize,
+		trimWhitespace: w.TrimWhitespace,
+		splitWord:      w.SplitWord,
+		measurer:       measurer,
+		columnWidth:    measurer.StringWidth(" "),
+	}
+}
+
+// Wrap wraps the input string to the advance width limit of the wrapper.
+//
+// Returns the wrapped string and a metadata sequence describing each
+// wrapped line, along with the advance width of each line. Tabs are
+// written as the number of spaces nearest to their advance.
+func (w ProportionalWrapper) Wrap(str string) (string, *ProportionalSeq, error) {
+	if w.Advances == nil {
+		return "", nil, errors.New("advances must not be nil")
+	}
+	if w.Limit <= 0 || math.IsInf(w.Limit, 0) || math.IsNaN(w.Limit) {
+		return "", nil, errors.New("limit must be greater than zero")
+	}
+	config := w.config()
+	if config.limit > unboundedLimit {
+		return "", nil, errors.New("limit is too large")
+	}
+
+	// a limit narrower than two fixed-point units still fits nothing
+	// wider than a zero-width cluster, which the engine rejects.
+	config.limit = max(config.limit, 2)
+	wrapped, seq, err := wrapTokensFrom(
+		tokenize(str, config.measurer), config, newPositions(1, 1, 0, 0),
+	)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// convert the widths back from fixed-point units.
+	advances := make([]float64, len(seq.WrappedLines))
+	for idx := range seq.WrappedLines {
+		wrappedLine := &seq.WrappedLines[idx]
+		advances[idx] = fromAdvanceUnits(wrappedLine.Width)
+		wrappedLine.Width = int(math.Ceil(advances[idx]))
+	}
+	seq.Limit = int(w.Limit)
+	return wrapped, &ProportionalSeq{
+		WrappedStringSeq: seq, Advances: advances, AdvanceLimit: w.Limit,
+	}, nil
+}
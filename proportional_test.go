@@ -0,0 +1,167 @@
+package stringwrap
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testAdvances is a table of glyph advances resembling a proportional
+// sans-serif font.
+var testAdvances = AdvanceTable{
+	Advances: map[rune]float64{'i': 2.5, 'l': 2.5, 'm': 9.5, 'w': 8.5, ' ': 3, '-': 4},
+	Default:  6,
+}
+
+// proportionalTestCase is a struct that contains the input string, the
+// wrapper configuration, and the expected wrapped string and advances.
+type proportionalTestCase struct {
+	input    string
+	wrapper  ProportionalWrapper
+	expected string
+	advances []float64
+}
+
+// TestProportionalWrapper tests wrapping with fractional advance widths
+// with a variety of test cases.
+func TestProportionalWrapper(t *testing.T) {
+	tests := []proportionalTestCase{
+		{
+			input:    "ill will swim in mild wind",
+			wrapper:  ProportionalWrapper{Limit: 30, TabSize: 4, TrimWhitespace: true, Advances: testAdvances},
+			expected: "ill will\nswim\nin\nmild\nwind",
+			advances: []float64{26.5, 26.5, 8.5, 20.5, 23},
+		},
+		{
+			input: "ill will swim in mild wind",
+			wrapper: ProportionalWrapper{
+				Limit: 30, TabSize: 4, TrimWhitespace: true, Advances: testAdvances, SpaceAdvance: 1.25,
+			},
+			expected: "ill will\nswim\nin\nmild\nwind",
+			advances: []float64{24.75, 26.5, 8.5, 20.5, 23},
+		},
+		{
+			input: "ill will swim in mild wind",
+			wrapper: ProportionalWrapper{
+				Limit: 20.5, TabSize: 4, TrimWhitespace: true, SplitWord: true, Advances: testAdvances,
+			},
+			expected: "ill\nwill\nsw-\nim\nin\nmild\nwi-\nnd",
+			advances: []float64{7.5, 16, 18.5, 12, 8.5, 20.5, 15, 12},
+		},
+		{
+			input: "wonderful\nmill",
+			wrapper: ProportionalWrapper{
+				Limit: 20.5, TabSize: 4, TrimWhitespace: true, SplitWord: true, Advances: testAdvances,
+			},
+			expected: "wo-\nnd-\ner-\nful\nmill",
+			advances: []float64{18.5, 16, 16, 14.5, 17},
+		},
+		{
+			input:    "wonderful\nmill",
+			wrapper:  ProportionalWrapper{Limit: 30, TabSize: 4, TrimWhitespace: true, Advances: testAdvances},
+			expected: "wonderful\nmill",
+			advances: []float64{53, 17},
+		},
+		{
+			input: "ab\tcd ef",
+			wrapper: ProportionalWrapper{
+				Limit: 30, TabSize: 4, TrimWhitespace: true, Advances: testAdvances, SpaceAdvance: 1.25,
+			},
+			expected: "ab  cd\nef",
+			advances: []float64{27, 12},
+		},
+		{
+			input:    "ab\tcd ef",
+			wrapper:  ProportionalWrapper{Limit: 30, TabSize: 2, TrimWhitespace: false, Advances: testAdvances},
+			expected: "ab  cd\n ef",
+			advances: []float64{30, 15},
+		},
+		{
+			input: "ill\u00A0wim",
+			wrapper: ProportionalWrapper{
+				Limit: 20, TabSize: 4, TrimWhitespace: true, SplitWord: true, Advances: testAdvances,
+			},
+			expected: "ill\u00A0wim",
+			advances: []float64{34},
+		},
+	}
+
+	for idx, tt := range tests {
+		t.Run(fmt.Sprintf("Proportional Test %d", idx+1), func(t *testing.T) {
+			wrapped, seq, err := tt.wrapper.Wrap(tt.input)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.expected, wrapped)
+			assert.Equal(t, tt.advances, seq.Advances)
+			assert.Equal(t, tt.wrapper.Limit, seq.AdvanceLimit)
+
+			// the metadata is the same as for terminal text.
+			for lineIdx, wrappedLine := range seq.WrappedLines {
+				lineAdvance := seq.Advances[lineIdx]
+				assert.Equal(t, lineIdx+1, wrappedLine.CurLineNum)
+				assert.Equal(t, lineAdvance > tt.wrapper.Limit, wrappedLine.NotWithinLimit)
+				assert.GreaterOrEqual(t, float64(wrappedLine.Width), lineAdvance)
+			}
+		})
+	}
+}
+
+// TestProportionalWrapper_Metadata tests that the offsets and hyphenation
+// of proportional lines map back to the original string.
+func TestProportionalWrapper_Metadata(t *testing.T) {
+	wrapper := ProportionalWrapper{
+		Limit: 20.5, TabSize: 4, TrimWhitespace: true, SplitWord: true, Advances: testAdvances,
+	}
+	_, seq, err := wrapper.Wrap("wonderful\nmill")
+	assert.Nil(t, err)
+	assert.Equal(t, 20, seq.Limit)
+	assert.True(t, seq.WordSplitAllowed)
+
+	expected := []WrappedString{
+		{
+			CurLineNum: 1, OrigLineNum: 1, SegmentInOrig: 1, Width: 19, EndsWithSplitWord: true,
+			OrigByteOffset: LineOffset{Start: 0, End: 2}, OrigRuneOffset: LineOffset{Start: 0, End: 2},
+			WrappedByteOffset: LineOffset{Start: 0, End: 3},
+		},
+		{
+			CurLineNum: 2, OrigLineNum: 1, SegmentInOrig: 2, Width: 16, EndsWithSplitWord: true,
+			OrigByteOffset: LineOffset{Start: 2, End: 4}, OrigRuneOffset: LineOffset{Start: 2, End: 4},
+			WrappedByteOffset: LineOffset{Start: 4, End: 7},
+		},
+		{
+			CurLineNum: 3, OrigLineNum: 1, SegmentInOrig: 3, Width: 16, EndsWithSplitWord: true,
+			OrigByteOffset: LineOffset{Start: 4, End: 6}, OrigRuneOffset: LineOffset{Start: 4, End: 6},
+			WrappedByteOffset: LineOffset{Start: 8, End: 11},
+		},
+		{
+			CurLineNum: 4, OrigLineNum: 1, SegmentInOrig: 4, Width: 15, LastSegmentInOrig: true,
+			IsHardBreak: true, Separator: SeparatorLF, OrigByteOffset: LineOffset{Start: 6, End: 10},
+			OrigRuneOffset: LineOffset{Start: 6, End: 10}, WrappedByteOffset: LineOffset{Start: 12, End: 15},
+		},
+		{
+			CurLineNum: 5, OrigLineNum: 2, SegmentInOrig: 1, Width: 17, LastSegmentInOrig: true,
+			OrigByteOffset: LineOffset{Start: 10, End: 14}, OrigRuneOffset: LineOffset{Start: 10, End: 14},
+			WrappedByteOffset: LineOffset{Start: 16, End: 20},
+		},
+	}
+	assert.Equal(t, expected, seq.WrappedLines)
+}
+
+// TestProportionalWrapper_Errors tests that invalid configurations are
+// rejected.
+func TestProportionalWrapper_Errors(t *testing.T) {
+	_, _, err := ProportionalWrapper{Limit: 10}.Wrap("abc")
+	assert.NotNil(t, err)
+
+	_, _, err = ProportionalWrapper{Limit: 0, Advances: testAdvances}.Wrap("abc")
+	assert.NotNil(t, err)
+
+	_, _, err = ProportionalWrapper{Limit: -1.5, Advances: testAdvances}.Wrap("abc")
+	assert.NotNil(t, err)
+
+	advances := AdvanceFunc(func(str string) float64 { return 0.5 })
+	wrapped, seq, err := ProportionalWrapper{Limit: 1.5, Advances: advances}.Wrap("abcd")
+	assert.Nil(t, err)
+	assert.Equal(t, "abcd", wrapped)
+	assert.Equal(t, []float64{2}, seq.Advances)
+}
@@ -0,0 +1,146 @@
+package stringwrap
+
+import (
+	"strings"
+	"unicode"
+)
+
+// lineKind classifies an original line when reflowing paragraphs
+type lineKind int
+
+const (
+	// lineText is a line of a paragraph, which is joined with the lines
+	// around it
+	lineText lineKind = iota
+	// lineBlank is an empty or whitespace-only line between paragraphs
+	lineBlank
+	// lineCode is an indented line of a code block, which is kept as it
+	// is
+	lineCode
+	// lineFence opens or closes a fenced code block
+	lineFence
+	// lineListItem starts a list item, which begins a new paragraph
+	lineListItem
+)
+
+// isListMarker returns true if the line starts with a bullet ("-", "*"
+// or "+") or a number followed by "." or ")", followed by whitespace
+func isListMarker(line string) bool {
+	line = strings.TrimLeftFunc(line, unicode.IsSpace)
+	idx := 0
+	for idx < len(line) && line[idx] >= '0' && line[idx] <= '9' {
+		idx++
+	}
+	switch {
+	case idx == 0 && len(line) > 0 && strings.ContainsRune("-*+", rune(line[0])):
+		idx = 1
+	case idx > 0 && idx < len(line) && (line[idx] == '.' || line[idx] == ')'):
+		idx++
+	default:
+		return false
+	}
+	return idx < len(line) && (line[idx] == ' ' || line[idx] == '\t')
+}
+
+// classifyLine returns the kind of an original line, given the kind of
+// the line before it
+func classifyLine(line string, prev lineKind) lineKind {
+	content := strings.TrimLeftFunc(line, unicode.IsSpace)
+	switch {
+	case content == "":
+		return lineBlank
+	case strings.HasPrefix(content, "```") || strings.HasPrefix(content, "~~~"):
+		return lineFence
+	case (strings.HasPrefix(line, "    ") || strings.HasPrefix(line, "\t")) &&
+		(prev == lineBlank || prev == lineCode):
+		// an indented line only starts a code block after a blank line,
+		// otherwise it continues the paragraph before it.
+		return lineCode
+	case isListMarker(line):
+		return lineListItem
+	}
+	return lineText
+}
+
+// reflowLine is a single original line of tokens, including the hard
+// break that ends it, if any
+type reflowLine struct {
+	tokens []Token
+	kind   lineKind
+}
+
+// reflowLines splits the tokens into original lines and classifies them,
+// treating every line within a fenced code block as code
+func reflowLines(tokens []Token) []reflowLine {
+	var lines []reflowLine
+	var text strings.Builder
+	start := 0
+	prev, inFence := lineBlank, false
+
+	for idx, token := range tokens {
+		if token.Kind != TokenHardBreak && idx < len(tokens)-1 {
+			if token.Kind != TokenANSI {
+				text.WriteString(token.Text)
+			}
+			continue
+		}
+		if token.Kind != TokenHardBreak && token.Kind != TokenANSI {
+			text.WriteString(token.Text)
+		}
+
+		kind := classifyLine(text.String(), prev)
+		switch {
+		case kind == lineFence:
+			inFence = !inFence
+		case inFence:
+			kind = lineCode
+		}
+		lines = append(lines, reflowLine{tokens: tokens[start : idx+1], kind: kind})
+		text.Reset()
+		start = idx + 1
+		prev = kind
+	}
+	return lines
+}
+
+// joins returns true if the break ending a line of the given kind joins
+// it with the next line
+func joins(kind lineKind, next lineKind) bool {
+	return (kind == lineText || kind == lineListItem) && next == lineText
+}
+
+// reflowTokens feeds the tokens to the state machine, joining the lines
+// of each paragraph into one and keeping code lines as they are
+func reflowTokens(stateMachine *wrapStateMachine, tokens []Token) {
+	config := stateMachine.config
+	lines := reflowLines(tokens)
+
+	for idx, line := range lines {
+		// code is never wrapped, and keeps its indentation.
+		stateMachine.config = config
+		if line.kind == lineCode || line.kind == lineFence {
+			stateMachine.config.noWrap = true
+			stateMachine.config.trimWhitespace = false
+		}
+		stateMachine.config.joinBreaks = idx+1 < len(lines) && joins(line.kind, lines[idx+1].kind)
+
+		for _, token := range line.tokens {
+			stateMachine.writeToken(token)
+		}
+	}
+}
+
+// wrapReflowTokens reflows and wraps a pre-tokenized string starting from
+// the first line
+func wrapReflowTokens(tokens []Token, config wordWrapConfig) (
+	string, *WrappedStringSeq, error,
+) {
+	if err := config.validate(); err != nil {
+		return "", nil, err
+	}
+
+	stateMachine := newStateMachine(config, newPositions(1, 1, 0, 0))
+	reflowTokens(stateMachine, tokens)
+	stateMachine.finish()
+	return stateMachine.buffer.String(), stateMachine.wrappedStringSeq, nil
+}
@@ -0,0 +1,179 @@
+package stringwrap
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// reflowTestCase is a struct that contains the input string, the wrapper
+// configuration, and the expected wrapped string and original line
+// numbers.
+type reflowTestCase struct {
+	input    string
+	wrapper  Wrapper
+	expected string
+	origLine []int
+}
+
+// TestWrapper_Reflow tests reflowing paragraphs with a variety of test
+// cases.
+func TestWrapper_Reflow(t *testing.T) {
+	tests := []reflowTestCase{
+		{
+			input:    "The quick brown fox \njumps over the\n  lazy dog.",
+			wrapper:  Wrapper{Limit: 24, TabSize: 4, TrimWhitespace: true, Reflow: true},
+			expected: "The quick brown fox\njumps over the lazy dog.",
+			origLine: []int{1, 2},
+		},
+		{
+			input:    "The quick brown fox \njumps over the\n  lazy dog.",
+			wrapper:  Wrapper{Limit: 50, TabSize: 4, TrimWhitespace: true, Reflow: true},
+			expected: "The quick brown fox jumps over the lazy dog.",
+			origLine: []int{1},
+		},
+		{
+			input:    "first paragraph\nwraps here\n\nsecond\nparagraph\n",
+			wrapper:  Wrapper{Limit: 40, TabSize: 4, TrimWhitespace: true, Reflow: true},
+			expected: "first paragraph wraps here\n\nsecond paragraph\n",
+			origLine: []int{1, 3, 4},
+		},
+		{
+			input:    "A list:\n- first item that\n  continues here\n2. second\n* third",
+			wrapper:  Wrapper{Limit: 40, TabSize: 4, TrimWhitespace: true, Reflow: true},
+			expected: "A list:\n- first item that continues here\n2. second\n* third",
+			origLine: []int{1, 2, 4, 5},
+		},
+		{
+			input:    "Example:\n\n    code  line one\n    code line two\nafter",
+			wrapper:  Wrapper{Limit: 10, TabSize: 4, TrimWhitespace: true, Reflow: true},
+			expected: "Example:\n\n    code  line one\n    code line two\nafter",
+			origLine: []int{1, 2, 3, 4, 5},
+		},
+		{
+			input:    "text\n```\nfenced   text\nmore\n```\nlast line\nand more",
+			wrapper:  Wrapper{Limit: 40, TabSize: 4, TrimWhitespace: true, Reflow: true},
+			expected: "text\n```\nfenced   text\nmore\n```\nlast line and more",
+			origLine: []int{1, 2, 3, 4, 5, 6},
+		},
+		{
+			input:    "indented\n    continuation\nline",
+			wrapper:  Wrapper{Limit: 40, TabSize: 4, TrimWhitespace: true, Reflow: true},
+			expected: "indented continuation line",
+			origLine: []int{1},
+		},
+		{
+			input:    "ab cd\nef gh\nij",
+			wrapper:  Wrapper{Limit: 5, TabSize: 4, TrimWhitespace: true, Reflow: true},
+			expected: "ab cd\nef gh\nij",
+			origLine: []int{1, 2, 3},
+		},
+		{
+			input:    "abc de\nfg hij",
+			wrapper:  Wrapper{Limit: 8, TabSize: 4, TrimWhitespace: true, Reflow: true},
+			expected: "abc de\nfg hij",
+			origLine: []int{1, 2},
+		},
+		{
+			input:    "abc d\nefg hij",
+			wrapper:  Wrapper{Limit: 9, TabSize: 4, TrimWhitespace: true, Reflow: true},
+			expected: "abc d efg\nhij",
+			origLine: []int{1, 2},
+		},
+	}
+
+	for idx, tt := range tests {
+		t.Run(fmt.Sprintf("Reflow Test %d", idx+1), func(t *testing.T) {
+			wrapped, seq, err := tt.wrapper.Wrap(tt.input)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.expected, wrapped)
+
+			origLine := make([]int, 0, len(seq.WrappedLines))
+			widths := make([]int, 0, len(seq.WrappedLines))
+			for _, wrappedLine := range seq.WrappedLines {
+				origLine = append(origLine, wrappedLine.OrigLineNum)
+				widths = append(widths, wrappedLine.Width)
+			}
+			assert.Equal(t, tt.origLine, origLine)
+
+			tokenWrapped, tokenSeq, err := tt.wrapper.WrapTokens(tt.wrapper.Tokenize(tt.input))
+			assert.Nil(t, err)
+			assert.Equal(t, wrapped, tokenWrapped)
+			assert.Equal(t, seq, tokenSeq)
+
+			measurement, err := tt.wrapper.Measure(tt.input, true)
+			assert.Nil(t, err)
+			assert.Equal(t, widths, measurement.Widths)
+		})
+	}
+}
+
+// TestWrapper_ReflowOffsets tests that the offsets of reflowed lines
+// span the joined lines of the original string.
+func TestWrapper_ReflowOffsets(t *testing.T) {
+	input := "The quick brown fox \njumps over the\n  lazy dog.\n\nend"
+	wrapper := Wrapper{Limit: 24, TabSize: 4, TrimWhitespace: true, Reflow: true}
+	_, seq, err := wrapper.Wrap(input)
+	assert.Nil(t, err)
+
+	expected := []WrappedString{
+		{
+			CurLineNum: 1, OrigLineNum: 1, SegmentInOrig: 1, Width: 19,
+			OrigByteOffset: LineOffset{Start: 0, End: 21}, OrigRuneOffset: LineOffset{Start: 0, End: 21},
+			WrappedByteOffset: LineOffset{Start: 0, End: 19},
+		},
+		{
+			CurLineNum: 2, OrigLineNum: 2, SegmentInOrig: 1, Width: 24, LastSegmentInOrig: true,
+			IsHardBreak: true, Separator: SeparatorLF, OrigByteOffset: LineOffset{Start: 21, End: 48},
+			OrigRuneOffset: LineOffset{Start: 21, End: 48}, WrappedByteOffset: LineOffset{Start: 20, End: 44},
+		},
+		{
+			CurLineNum: 3, OrigLineNum: 4, SegmentInOrig: 1, LastSegmentInOrig: true, IsHardBreak: true,
+			Separator: SeparatorLF, OrigByteOffset: LineOffset{Start: 48, End: 49},
+			OrigRuneOffset: LineOffset{Start: 48, End: 49}, WrappedByteOffset: LineOffset{Start: 45, End: 45},
+		},
+		{
+			CurLineNum: 4, OrigLineNum: 5, SegmentInOrig: 1, Width: 3, LastSegmentInOrig: true,
+			OrigByteOffset: LineOffset{Start: 49, End: 52}, OrigRuneOffset: LineOffset{Start: 49, End: 52},
+			WrappedByteOffset: LineOffset{Start: 46, End: 49},
+		},
+	}
+	assert.Equal(t, expected, seq.WrappedLines)
+
+	wrapper.MaxLines = 2
+	wrapper.Ellipsis = "…"
+	wrapped, _, err := wrapper.Wrap(input)
+	assert.Nil(t, err)
+	assert.Equal(t, "The quick brown fox\njumps over the lazy dog…", wrapped)
+}
+
+// TestClassifyLine tests the classification of original lines when
+// reflowing with a variety of test cases.
+func TestClassifyLine(t *testing.T) {
+	tests := []struct {
+		line     string
+		prev     lineKind
+		expected lineKind
+	}{
+		{line: "plain text", prev: lineBlank, expected: lineText},
+		{line: "   ", prev: lineText, expected: lineBlank},
+		{line: "    code", prev: lineBlank, expected: lineCode},
+		{line: "\tcode", prev: lineCode, expected: lineCode},
+		{line: "    continued", prev: lineText, expected: lineText},
+		{line: "```go", prev: lineText, expected: lineFence},
+		{line: "~~~", prev: lineBlank, expected: lineFence},
+		{line: "- item", prev: lineText, expected: lineListItem},
+		{line: "  * item", prev: lineText, expected: lineListItem},
+		{line: "12) item", prev: lineText, expected: lineListItem},
+		{line: "3. item", prev: lineText, expected: lineListItem},
+		{line: "-item", prev: lineText, expected: lineText},
+		{line: "3.14 is pi", prev: lineText, expected: lineText},
+	}
+
+	for idx, tt := range tests {
+		t.Run(fmt.Sprintf("Classify Line Test %d", idx+1), func(t *testing.T) {
+			assert.Equal(t, tt.expected, classifyLine(tt.line, tt.prev))
+		})
+	}
+}
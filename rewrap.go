@@ -0,0 +1,245 @@
+package stringwrap
+
+import (
+	"errors"
+	"sort"
+)
+
+// Edit describes the replacement of the byte range [Start, End) of an
+// original string with Text.
+type Edit struct {
+	// The byte offset where the replaced range starts.
+	Start int
+	// The byte offset where the replaced range ends.
+	End int
+	// The text that replaces the range.
+	Text string
+}
+
+// Apply returns the string with the edit applied.
+func (e Edit) Apply(str string) string {
+	return str[:e.Start] + e.Text + str[e.End:]
+}
+
+// nthLineOffset returns the byte offset in the wrapped output where
+// wrapped line n starts, with lines counted from zero
+func nthLineOffset(wrapped string, wrappedLines []WrappedString, n int) int {
+	if n >= len(wrappedLines) {
+		return len(wrapped)
+	}
+	return wrappedLines[n].WrappedByteOffset.Start
+}
+
+// lineAtByte returns the index of the last wrapped line that starts at
+// or before the given byte offset in the original string
+func lineAtByte(wrappedLines []WrappedString, byteIdx int) int {
+	idx := sort.Search(len(wrappedLines), func(i int) bool {
+		return wrappedLines[i].OrigByteOffset.Start > byteIdx
+	})
+	return max(idx-1, 0)
+}
+
+// wrapper returns a Wrapper with the configuration recorded in the
+// metadata sequence
+func (s *WrappedStringSeq) wrapper() Wrapper {
+	return Wrapper{
+		Limit:              s.Limit,
+		TabSize:            s.TabSize,
+		TabStops:           s.TabStops,
+		KeepTabs:           s.KeepTabs,
+		TrimWhitespace:     s.TrimWhitespace,
+		SplitWord:          s.WordSplitAllowed,
+		Metric:             s.Metric,
+		WhiteSpace:         s.WhiteSpace,
+		Reflow:             s.Reflow,
+		CarriageReturn:     s.CarriageReturn,
+		LineBreak:          s.LineBreak,
+		SoftBreak:          s.SoftBreak,
+		PreserveSeparators: s.PreserveSeparators,
+		LeadingMarker:      s.LeadingMarker,
+		TrailingMarker:     s.TrailingMarker,
+		MaxLines:           s.MaxLines,
+		Ellipsis:           s.Ellipsis,
+		EllipsisPlacement:  s.EllipsisPlacement,
+	}
+}
+
+// RewrapEdit applies an edit to a string that was previously wrapped into
+// wrapped and seq, and rewraps only the original lines touched by the
+// edit, reusing the configuration recorded in seq. If the edit changes
+// where the following original lines start, such as by removing a hard
+// break, they are rewrapped too. A sequence that was wrapped with
+// MaxLines, Reflow, WhiteSpaceNormal or a CarriageReturn other than
+// CarriageReturnBreak is wrapped from scratch, as truncation depends on
+// every line and the others join original lines.
+//
+// The rewrapped lines are spliced into the existing output and metadata,
+// and the offsets and line numbers of every later line are shifted to
+// account for the edit. The result is identical to wrapping the edited
+// string from scratch.
+//
+// A custom Measurer cannot be recorded in seq, so an error is returned
+// if seq was measured with one. Use Wrapper.RewrapEdit instead.
+func RewrapEdit(str string, wrapped string, seq *WrappedStringSeq, edit Edit) (
+	string, *WrappedStringSeq, error,
+) {
+	if seq.CustomMeasurer {
+		return "", nil, errors.New("seq was measured with a custom measurer")
+	}
+	return seq.wrapper().RewrapEdit(str, wrapped, seq, edit)
+}
+
+// RewrapEdit applies an edit like the RewrapEdit function, but rewraps
+// with the configuration of the wrapper, including its Measurer, rather
+// than the configuration recorded in seq. The wrapper must be the one
+// that produced wrapped and seq.
+func (w Wrapper) RewrapEdit(str string, wrapped string, seq *WrappedStringSeq, edit Edit) (
+	string, *WrappedStringSeq, error,
+) {
+	if edit.Start < 0 || edit.Start > edit.End || edit.End > len(str) {
+		return "", nil, errors.New("edit is out of range")
+	}
+
+	newStr := edit.Apply(str)
+
+	// truncation depends on every line of the string, and reflowing,
+	// collapsing hard breaks or not breaking at carriage returns joins
+	// the original lines, so the edited string is wrapped from scratch.
+	if w.MaxLines > 0 || w.Reflow || w.WhiteSpace == WhiteSpaceNormal ||
+		w.CarriageReturn != CarriageReturnBreak {
+		return w.Wrap(newStr)
+	}
+
+	config := w.config()
+	oldLines := seq.WrappedLines
+	byteDelta := len(edit.Text) - (edit.End - edit.Start)
+
+	// joinsCRLF returns true if the byte offset of the edited string falls
+	// between a carriage return and a line feed, which are a single break.
+	joinsCRLF := func(byteIdx int) bool {
+		return byteIdx > 0 && byteIdx < len(newStr) &&
+			newStr[byteIdx-1] == '\r' && newStr[byteIdx] == '\n'
+	}
+
+	// find the first wrapped line of the original line touched by the
+	// start of the edit, and the positional state at the start of it.
+	// Since the string before the edit is unchanged, so is the state.
+	first := 0
+	pos := newPositions(1, 1, 0, 0)
+	if len(oldLines) > 0 {
+		first = lineAtByte(oldLines, edit.Start)
+		for first > 0 && oldLines[first].SegmentInOrig > 1 {
+			first--
+		}
+
+		// a line feed that now follows the carriage return ending the
+		// previous original line joins that line too.
+		for first > 0 && joinsCRLF(oldLines[first].OrigByteOffset.Start) {
+			first--
+			for first > 0 && oldLines[first].SegmentInOrig > 1 {
+				first--
+			}
+		}
+
+		firstLine := oldLines[first]
+		pos = newPositions(
+			firstLine.CurLineNum,
+			firstLine.OrigLineNum,
+			firstLine.OrigByteOffset.Start,
+			firstLine.OrigRuneOffset.Start,
+		)
+		pos.wrappedByte = firstLine.WrappedByteOffset.Start
+	}
+
+	// oldBoundary returns the index of the first wrapped line that starts
+	// at or after the byte offset of the original string, and whether an
+	// original line of the previous wrapping ends at the offset.
+	oldBoundary := func(byteIdx int) (int, bool) {
+		idx := sort.Search(len(oldLines), func(i int) bool {
+			return oldLines[i].OrigByteOffset.Start >= byteIdx
+		})
+		if idx == 0 {
+			return idx, byteIdx == 0
+		}
+		prev := oldLines[idx-1]
+		return idx, prev.IsHardBreak && prev.OrigByteOffset.End == byteIdx
+	}
+
+	// extend the rewrapped region over whole original lines of the
+	// edited string until it passes the end of the edit and ends where an
+	// original line of the previous wrapping also ended, so the lines
+	// after it are wrapped exactly as before.
+	regionStart := pos.origCurByte
+	regionEnd := regionStart
+	last := len(oldLines)
+	for regionEnd < len(newStr) {
+		regionEnd, _ = origLineEnd(newStr, regionEnd)
+		if regionEnd < edit.Start+len(edit.Text) || regionEnd == len(newStr) {
+			continue
+		}
+		if idx, ok := oldBoundary(regionEnd - byteDelta); ok && idx < len(oldLines) {
+			last = idx
+			break
+		}
+	}
+
+	oldEndRune := pos.origCurRune
+	if last > first {
+		oldEndRune = oldLines[last-1].OrigRuneOffset.End
+	}
+	outStart := pos.wrappedByte
+	outEnd := nthLineOffset(wrapped, oldLines, last)
+	region := newStr[regionStart:regionEnd]
+	regionTokens := shiftTokens(tokenize(region, config.measurer), regionStart, pos.origCurRune)
+	regionWrapped, regionSeq, err := wrapTokensFrom(regionTokens, config, pos)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// the lines after the rewrapped region keep their wrapping, and only
+	// need their offsets and line numbers shifted.
+	regionRunes := 0
+	if len(regionTokens) > 0 {
+		regionRunes = regionTokens[len(regionTokens)-1].RuneOffset.End - pos.origCurRune
+	}
+	runeDelta := regionRunes - (oldEndRune - pos.origCurRune)
+	wrappedDelta := len(regionWrapped) - (outEnd - outStart)
+	curLineDelta := len(regionSeq.WrappedLines) - (last - first)
+	origLineDelta := 0
+	if last < len(oldLines) {
+		breaks := 0
+		for _, token := range regionTokens {
+			if token.Kind == TokenHardBreak {
+				breaks++
+			}
+		}
+		origLineDelta = pos.origLineNum + breaks - oldLines[last].OrigLineNum
+	}
+
+	var newLines []WrappedString
+	newLines = append(newLines, oldLines[:first]...)
+	newLines = append(newLines, regionSeq.WrappedLines...)
+	for _, wrappedLine := range oldLines[last:] {
+		wrappedLine.CurLineNum += curLineDelta
+		wrappedLine.OrigLineNum += origLineDelta
+		wrappedLine.OrigByteOffset.Start += byteDelta
+		wrappedLine.OrigByteOffset.End += byteDelta
+		wrappedLine.OrigRuneOffset.Start += runeDelta
+		wrappedLine.OrigRuneOffset.End += runeDelta
+		wrappedLine.WrappedByteOffset.Start += wrappedDelta
+		wrappedLine.WrappedByteOffset.End += wrappedDelta
+		newLines = append(newLines, wrappedLine)
+	}
+
+	// a trailing region that wraps to no lines, such as trimmed
+	// whitespace, is still spanned by the line before it.
+	if regionEnd == len(newStr) && len(regionSeq.WrappedLines) == 0 && first > 0 {
+		newLines[first-1].OrigByteOffset.End = regionEnd
+		newLines[first-1].OrigRuneOffset.End = pos.origCurRune + regionRunes
+	}
+
+	// splice the rewrapped output into the previous output.
+	newSeq := *seq
+	newSeq.WrappedLines = newLines
+	return wrapped[:outStart] + regionWrapped + wrapped[outEnd:], &newSeq, nil
+}
@@ -0,0 +1,353 @@
+package stringwrap
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRewrapEdit tests that rewrapping a string after an edit produces the
+// same output and metadata as wrapping the edited string from scratch.
+func TestRewrapEdit(t *testing.T) {
+	input := "The quick brown fox jumps over the lazy dog\n\n" +
+		"\tHello world! 🌟 with \x1b[31mcolored\x1b[0m words\n" +
+		"Supercalifragilisticexpialidocious short\nend"
+
+	edits := []Edit{
+		// insert within a single line
+		{Start: 4, End: 4, Text: "very "},
+		// replace a word with a longer one
+		{Start: 10, End: 15, Text: "reddish-brown"},
+		// delete a word
+		{Start: 4, End: 10, Text: ""},
+		// insert a hard break
+		{Start: 20, End: 20, Text: "\n"},
+		// remove the hard break between two lines
+		{Start: 43, End: 44, Text: ""},
+		// replace text spanning several lines
+		{Start: 30, End: 70, Text: "over 🌟 the\nmoon"},
+		// edit the last line
+		{Start: 133, End: 136, Text: "the end of it all"},
+		// append to the end
+		{Start: 136, End: 136, Text: " and more"},
+		// append a hard break to the end
+		{Start: 136, End: 136, Text: "\n"},
+		// delete the last line
+		{Start: 132, End: 136, Text: ""},
+		// delete everything
+		{Start: 0, End: 136, Text: ""},
+		// edit at the very start
+		{Start: 0, End: 0, Text: "Well, "},
+		// insert a multi-byte rune
+		{Start: 50, End: 50, Text: "é"},
+	}
+
+	for _, splitWord := range []bool{false, true} {
+		wrapped, seq, _ := stringWrap(input, 10, 4, true, splitWord)
+		for idx, edit := range edits {
+			name := fmt.Sprintf("Rewrap Edit Test %t/%d", splitWord, idx+1)
+			t.Run(name, func(t *testing.T) {
+				rewrapped, rewrappedSeq, err := RewrapEdit(input, wrapped, seq, edit)
+				assert.Nil(t, err)
+
+				expected, expectedSeq, _ := stringWrap(
+					edit.Apply(input), 10, 4, true, splitWord,
+				)
+				assert.Equal(t, expected, rewrapped)
+				assert.Equal(t, expectedSeq, rewrappedSeq)
+			})
+		}
+	}
+}
+
+// TestRewrapEdit_Sequence tests that a series of edits applied one after
+// another keeps the output and metadata equivalent to a full rewrap.
+func TestRewrapEdit_Sequence(t *testing.T) {
+	str := ""
+	wrapped, seq, _ := StringWrap(str, 12, 4, false)
+
+	// simulate typing a paragraph one keystroke at a time, including
+	// backspacing over a typo.
+	keystrokes := []Edit{}
+	for _, r := range "Typing into an editr" {
+		keystrokes = append(keystrokes, Edit{Text: string(r)})
+	}
+	keystrokes = append(keystrokes, Edit{Text: "\b"})
+	for _, r := range "or\n\tone key at a time\n" {
+		keystrokes = append(keystrokes, Edit{Text: string(r)})
+	}
+
+	for idx, edit := range keystrokes {
+		edit.Start, edit.End = len(str), len(str)
+		if edit.Text == "\b" {
+			edit.Start, edit.Text = len(str)-1, ""
+		}
+
+		var err error
+		wrapped, seq, err = RewrapEdit(str, wrapped, seq, edit)
+		assert.Nil(t, err)
+		str = edit.Apply(str)
+
+		expected, expectedSeq, _ := StringWrap(str, 12, 4, false)
+		assert.Equal(t, expected, wrapped, fmt.Sprintf("keystroke %d", idx+1))
+		assert.Equal(t, expectedSeq, seq, fmt.Sprintf("keystroke %d", idx+1))
+	}
+
+	_, _, err := RewrapEdit(str, wrapped, seq, Edit{Start: 5, End: 2})
+	assert.NotNil(t, err)
+}
+
+// TestRewrapEdit_CRLF tests that edits which join a carriage return and a
+// line feed into a single break, or separate them, rewrap the original
+// lines on both sides of the break.
+func TestRewrapEdit_CRLF(t *testing.T) {
+	input := "The quick\r\nbrown fox\rjumps over\n\nend"
+	edits := []Edit{
+		// insert a line feed after a lone carriage return
+		{Start: 21, End: 21, Text: "\n"},
+		// insert text between a carriage return and a line feed
+		{Start: 10, End: 10, Text: "x"},
+		// replace a line feed with a carriage return before a line feed
+		{Start: 31, End: 32, Text: "\r"},
+		// delete the text between a carriage return and a line feed
+		{Start: 21, End: 31, Text: ""},
+		// delete the carriage return of a CRLF
+		{Start: 9, End: 10, Text: ""},
+	}
+
+	wrapped, seq, _ := StringWrap(input, 10, 4, true)
+	for idx, edit := range edits {
+		t.Run(fmt.Sprintf("Rewrap Edit CRLF Test %d", idx+1), func(t *testing.T) {
+			rewrapped, rewrappedSeq, err := RewrapEdit(input, wrapped, seq, edit)
+			assert.Nil(t, err)
+
+			expected, expectedSeq, _ := StringWrap(edit.Apply(input), 10, 4, true)
+			assert.Equal(t, expected, rewrapped)
+			assert.Equal(t, expectedSeq, rewrappedSeq)
+		})
+	}
+
+	// a line feed appended after a trailing carriage return joins it.
+	wrapped, seq, _ = StringWrap("ab\r", 10, 4, true)
+	rewrapped, rewrappedSeq, err := RewrapEdit("ab\r", wrapped, seq, Edit{Start: 3, End: 3, Text: "\ncd"})
+	assert.Nil(t, err)
+	expected, expectedSeq, _ := StringWrap("ab\r\ncd", 10, 4, true)
+	assert.Equal(t, expected, rewrapped)
+	assert.Equal(t, expectedSeq, rewrappedSeq)
+}
+
+// TestRewrapEdit_Markers tests that rewrapping after an edit writes the
+// continuation markers recorded in the metadata.
+func TestRewrapEdit_Markers(t *testing.T) {
+	input := "The quick brown fox jumps over the lazy dog\nSupercalifragilistic\nend"
+	wrapper := Wrapper{
+		Limit: 12, TabSize: 4, TrimWhitespace: true, SplitWord: true,
+		LeadingMarker: "↪ ", TrailingMarker: "↩",
+	}
+	wrapped, seq, _ := wrapper.Wrap(input)
+
+	edits := []Edit{
+		{Start: 4, End: 4, Text: "very "},
+		{Start: 43, End: 44, Text: " "},
+		{Start: 64, End: 67, Text: "the end of it all"},
+		{Start: 0, End: 44, Text: ""},
+	}
+	for idx, edit := range edits {
+		t.Run(fmt.Sprintf("Rewrap Edit Marker Test %d", idx+1), func(t *testing.T) {
+			rewrapped, rewrappedSeq, err := RewrapEdit(input, wrapped, seq, edit)
+			assert.Nil(t, err)
+
+			expected, expectedSeq, _ := wrapper.Wrap(edit.Apply(input))
+			assert.Equal(t, expected, rewrapped)
+			assert.Equal(t, expectedSeq, rewrappedSeq)
+		})
+	}
+}
+
+// TestRewrapEdit_Regions tests edits whose rewrapped region has to grow
+// past the original lines touched by the edit, or wraps to no lines.
+func TestRewrapEdit_Regions(t *testing.T) {
+	tests := []struct {
+		wrapper Wrapper
+		input   string
+		edit    Edit
+	}{
+		{
+			wrapper: Wrapper{Limit: 7, TrimWhitespace: true},
+			input:   "  ",
+			edit:    Edit{Start: 0, End: 2, Text: "\n"},
+		},
+		{
+			wrapper: Wrapper{Limit: 3, TrimWhitespace: true},
+			input:   "    ",
+			edit:    Edit{Start: 1, End: 3, Text: "\n"},
+		},
+		{
+			wrapper: Wrapper{Limit: 5, TrimWhitespace: true},
+			input:   "a    b\n  ",
+			edit:    Edit{Start: 0, End: 7, Text: ""},
+		},
+		{
+			wrapper: Wrapper{Limit: 6, TabSize: 4, TrimWhitespace: true},
+			input:   "a \tbc  ",
+			edit:    Edit{Start: 5, End: 6, Text: "bc"},
+		},
+		{
+			wrapper: Wrapper{Limit: 4, TabSize: 4, TrimWhitespace: true},
+			input:   "ab cd ef",
+			edit:    Edit{Start: 8, End: 8, Text: "\n"},
+		},
+		{
+			wrapper: Wrapper{Limit: 6, TrimWhitespace: true, LeadingMarker: "> "},
+			input:   "al bc alia\nx",
+			edit:    Edit{Start: 2, End: 5, Text: ""},
+		},
+		{
+			wrapper: Wrapper{Limit: 4, TabSize: 4, TrimWhitespace: true},
+			input:   "\r\n \x1b[31ma\u00a0b  \n ",
+			edit:    Edit{Start: 0, End: 2, Text: ""},
+		},
+		{
+			wrapper: Wrapper{Limit: 4, TabSize: 4},
+			input:   "ab \x1b[31mcd\x1b[0m\nef",
+			edit:    Edit{Start: 5, End: 7, Text: ""},
+		},
+	}
+
+	for idx, tt := range tests {
+		t.Run(fmt.Sprintf("Rewrap Edit Region Test %d", idx+1), func(t *testing.T) {
+			wrapped, seq, _ := tt.wrapper.Wrap(tt.input)
+			rewrapped, rewrappedSeq, err := RewrapEdit(tt.input, wrapped, seq, tt.edit)
+			assert.Nil(t, err)
+
+			expected, expectedSeq, _ := tt.wrapper.Wrap(tt.edit.Apply(tt.input))
+			assert.Equal(t, expected, rewrapped)
+			assert.Equal(t, expectedSeq, rewrappedSeq)
+		})
+	}
+}
+
+// TestRewrapEdit_Random tests that random edits of random strings, applied
+// one after another, keep the output and metadata equivalent to a full
+// rewrap.
+func TestRewrapEdit_Random(t *testing.T) {
+	pieces := []string{
+		"a", "bc", "word", "日本", " ", "  ", "\t", "\u00a0", "\u3000",
+		"\n", "\r", "\r\n", "\u2028", "\v", "\x1b[31m", "\x1b[0m", "\x1b]0;t\x1b\\",
+	}
+	rng := rand.New(rand.NewSource(1))
+	randomString := func(n int) string {
+		var b strings.Builder
+		for i := 0; i < n; i++ {
+			b.WriteString(pieces[rng.Intn(len(pieces))])
+		}
+		return b.String()
+	}
+	// randomOffset returns a random rune boundary of the string at or
+	// after the given byte offset.
+	randomOffset := func(str string, from int) int {
+		idx := from + rng.Intn(len(str)-from+1)
+		for idx < len(str) && !utf8.RuneStart(str[idx]) {
+			idx++
+		}
+		return idx
+	}
+
+	for idx := 0; idx < 3000; idx++ {
+		wrapper := Wrapper{
+			Limit:              4 + rng.Intn(5),
+			TabSize:            4,
+			TrimWhitespace:     rng.Intn(2) == 0,
+			SplitWord:          rng.Intn(2) == 0,
+			KeepTabs:           rng.Intn(4) == 0,
+			PreserveSeparators: rng.Intn(4) == 0,
+		}
+		if rng.Intn(3) == 0 {
+			wrapper.LeadingMarker = "> "
+			wrapper.TrailingMarker = "\\"
+			wrapper.Limit += 3
+		}
+
+		str := randomString(rng.Intn(10))
+		wrapped, seq, err := wrapper.Wrap(str)
+		assert.Nil(t, err)
+		for i := 0; i < 3; i++ {
+			start := randomOffset(str, 0)
+			edit := Edit{Start: start, End: randomOffset(str, start), Text: randomString(rng.Intn(3))}
+			msg := fmt.Sprintf("case %d: %q %+v", idx+1, str, edit)
+
+			wrapped, seq, err = RewrapEdit(str, wrapped, seq, edit)
+			assert.Nil(t, err, msg)
+			str = edit.Apply(str)
+
+			expected, expectedSeq, _ := wrapper.Wrap(str)
+			if !assert.Equal(t, expected, wrapped, msg) || !assert.Equal(t, expectedSeq, seq, msg) {
+				return
+			}
+		}
+	}
+}
+
+// TestRewrapEdit_Wrappers tests that rewrapping after an edit uses the
+// options of the wrapper recorded in the metadata.
+func TestRewrapEdit_Wrappers(t *testing.T) {
+	input := "The quick brown fox jumps over the lazy 日本 dog\n\n" +
+		"Supercalifragilistic  words\nend\r\tthe \rfinal line"
+	wrappers := []Wrapper{
+		{Limit: 10, TabSize: 4, TrimWhitespace: true, MaxLines: 3, Ellipsis: "…"},
+		{
+			Limit: 10, TabSize: 4, TrimWhitespace: true, SplitWord: true,
+			MaxLines: 4, EllipsisPlacement: EllipsisMiddle,
+		},
+		{Limit: 12, TabSize: 4, TrimWhitespace: true, Measurer: WidthFunc(doubleWidth)},
+		{Limit: 10, TabSize: 4, SplitWord: true, Measurer: wideHyphen},
+		{Limit: 10, TabSize: 4, TrimWhitespace: true, Metric: MetricBytes},
+		{
+			Limit: 10, TabSize: 4, SplitWord: true, Metric: MetricUTF16,
+			Measurer: WidthFunc(doubleWidth),
+		},
+		{Limit: 10, TabSize: 4, WhiteSpace: WhiteSpaceNormal},
+		{Limit: 10, TabSize: 4, WhiteSpace: WhiteSpacePreLine},
+		{Limit: 10, TabSize: 4, WhiteSpace: WhiteSpacePreWrap},
+		{Limit: 10, TabSize: 4, WhiteSpace: WhiteSpacePre},
+		{Limit: 10, TabSize: 4, TrimWhitespace: true, Reflow: true},
+		{Limit: 10, TabSize: 4, CarriageReturn: CarriageReturnIgnore},
+		{Limit: 10, TabSize: 4, KeepTabs: true, CarriageReturn: CarriageReturnOverwrite},
+	}
+	edits := []Edit{
+		{Start: 4, End: 4, Text: "very "},
+		{Start: 50, End: 52, Text: " "},
+		{Start: 0, End: 73, Text: ""},
+		{Start: 84, End: 85, Text: "more text "},
+		{Start: 89, End: 89, Text: "\n"},
+	}
+
+	for idx, wrapper := range wrappers {
+		wrapped, seq, _ := wrapper.Wrap(input)
+		for editIdx, edit := range edits {
+			name := fmt.Sprintf("Rewrap Edit Wrapper Test %d/%d", idx+1, editIdx+1)
+			t.Run(name, func(t *testing.T) {
+				rewrapped, rewrappedSeq, err := wrapper.RewrapEdit(input, wrapped, seq, edit)
+				assert.Nil(t, err)
+
+				expected, expectedSeq, _ := wrapper.Wrap(edit.Apply(input))
+				assert.Equal(t, expected, rewrapped)
+				assert.Equal(t, expectedSeq, rewrappedSeq)
+
+				// the function rewraps with the configuration recorded in
+				// seq, which cannot hold a custom measurer.
+				rewrapped, rewrappedSeq, err = RewrapEdit(input, wrapped, seq, edit)
+				if wrapper.Measurer != nil && wrapper.Metric == MetricWidth {
+					assert.NotNil(t, err)
+				} else {
+					assert.Equal(t, expected, rewrapped)
+					assert.Equal(t, expectedSeq, rewrappedSeq)
+				}
+			})
+		}
+	}
+}
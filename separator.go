@@ -0,0 +1,80 @@
+package stringwrap
+
+// LineSeparator is the character sequence that ended an original line.
+type LineSeparator int
+
+const (
+	// SeparatorNone is recorded for lines that did not end at a hard
+	// break, such as soft-wrapped lines and the end of the string.
+	SeparatorNone LineSeparator = iota
+	// SeparatorLF is a line feed ("\n").
+	SeparatorLF
+	// SeparatorCRLF is a carriage return followed by a line feed
+	// ("\r\n"), which is a single hard break.
+	SeparatorCRLF
+	// SeparatorCR is a carriage return ("\r") on its own.
+	SeparatorCR
+	// SeparatorNEL is a next line character (U+0085).
+	SeparatorNEL
+	// SeparatorLS is a Unicode line separator (U+2028).
+	SeparatorLS
+	// SeparatorPS is a Unicode paragraph separator (U+2029).
+	SeparatorPS
+)
+
+// separatorKind returns the kind of the text of a hard break
+func separatorKind(text string) LineSeparator {
+	switch text {
+	case "\n":
+		return SeparatorLF
+	case "\r\n":
+		return SeparatorCRLF
+	case "\r":
+		return SeparatorCR
+	case "\u0085":
+		return SeparatorNEL
+	case "\u2028":
+		return SeparatorLS
+	case "\u2029":
+		return SeparatorPS
+	}
+	return SeparatorNone
+}
+
+// Text returns the character sequence of the separator, or an empty
+// string for SeparatorNone.
+func (s LineSeparator) Text() string {
+	switch s {
+	case SeparatorLF:
+		return "\n"
+	case SeparatorCRLF:
+		return "\r\n"
+	case SeparatorCR:
+		return "\r"
+	case SeparatorNEL:
+		return "\u0085"
+	case SeparatorLS:
+		return "\u2028"
+	case SeparatorPS:
+		return "\u2029"
+	}
+	return ""
+}
+
+// CarriageReturn determines how a carriage return that is not followed
+// by a line feed is handled. A carriage return followed by a line feed
+// is always a single hard break.
+type CarriageReturn int
+
+const (
+	// CarriageReturnBreak treats a lone carriage return as a hard break.
+	CarriageReturnBreak CarriageReturn = iota
+	// CarriageReturnIgnore drops a lone carriage return from the output,
+	// ending the word before it without breaking the line.
+	CarriageReturnIgnore
+	// CarriageReturnOverwrite moves back to the start of the current
+	// wrapped line, like a terminal, so the text after the carriage
+	// return is written over the text before it. Columns that the later
+	// text does not reach keep the earlier text.
+	CarriageReturnOverwrite
+)
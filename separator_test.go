@@ -0,0 +1,281 @@
+package stringwrap
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestLineSeparator_Text tests that each separator is recognized from its
+// text, and that its text is returned.
+func TestLineSeparator_Text(t *testing.T) {
+	separators := []LineSeparator{
+		SeparatorLF, SeparatorCRLF, SeparatorCR, SeparatorNEL, SeparatorLS, SeparatorPS,
+	}
+	for idx, separator := range separators {
+		t.Run(fmt.Sprintf("Line Separator Test %d", idx+1), func(t *testing.T) {
+			assert.Equal(t, separator, separatorKind(separator.Text()))
+		})
+	}
+	assert.Equal(t, "", SeparatorNone.Text())
+	assert.Equal(t, SeparatorNone, separatorKind(" "))
+}
+
+// TestStringWrap_Separators tests that CRLF is a single hard break, and
+// that the separator ending each original line is recorded.
+func TestStringWrap_Separators(t *testing.T) {
+	input := "one\r\ntwo\rthree\nfour\u0085five\u2028six\u2029seven eight"
+	wrapped, seq, err := StringWrap(input, 6, 4, true)
+	assert.Nil(t, err)
+	assert.Equal(t, "one\ntwo\nthree\nfour\nfive\nsix\nseven\neight", wrapped)
+
+	var separators []LineSeparator
+	var origLines []int
+	for _, wrappedLine := range seq.WrappedLines {
+		separators = append(separators, wrappedLine.Separator)
+		origLines = append(origLines, wrappedLine.OrigLineNum)
+	}
+	assert.Equal(t, []LineSeparator{
+		SeparatorCRLF, SeparatorCR, SeparatorLF, SeparatorNEL,
+		SeparatorLS, SeparatorPS, SeparatorNone, SeparatorNone,
+	}, separators)
+	assert.Equal(t, []int{1, 2, 3, 4, 5, 6, 7, 7}, origLines)
+	assert.Equal(t, LineOffset{Start: 0, End: 5}, seq.WrappedLines[0].OrigByteOffset)
+	assert.Equal(t, LineOffset{Start: 0, End: 5}, seq.WrappedLines[0].OrigRuneOffset)
+}
+
+// carriageReturnTestCase is a struct that contains the input string, the
+// policy for lone carriage returns, and the expected wrapped string and
+// original line numbers.
+type carriageReturnTestCase struct {
+	input    string
+	policy   CarriageReturn
+	expected string
+	origLine []int
+}
+
+// TestWrapper_CarriageReturn tests each policy for lone carriage returns
+// with a variety of test cases.
+func TestWrapper_CarriageReturn(t *testing.T) {
+	tests := []carriageReturnTestCase{
+		{
+			input:    "Loading...\rDone\r\nnext",
+			policy:   CarriageReturnBreak,
+			expected: "Loading...\nDone\nnext",
+			origLine: []int{1, 2, 3},
+		},
+		{
+			input:    "Loading...\rDone\r\nnext",
+			policy:   CarriageReturnIgnore,
+			expected: "Loading...\nDone\nnext",
+			origLine: []int{1, 1, 2},
+		},
+		{
+			input:    "tick\rtock",
+			policy:   CarriageReturnIgnore,
+			expected: "ticktock",
+			origLine: []int{1},
+		},
+		{
+			input:    "Loading...\rDone\r\nnext",
+			policy:   CarriageReturnOverwrite,
+			expected: "Doneing...\nnext",
+			origLine: []int{1, 2},
+		},
+		{
+			input:    "50%\r100% complete and more",
+			policy:   CarriageReturnOverwrite,
+			expected: "100%\ncomplete and\nmore",
+			origLine: []int{1, 1, 1},
+		},
+		{
+			input:    "first\rsecond\rab",
+			policy:   CarriageReturnOverwrite,
+			expected: "abcond",
+			origLine: []int{1},
+		},
+		{
+			input:    "\x1b[31mred\x1b[0m text\r\x1b[1mX\x1b[0m",
+			policy:   CarriageReturnOverwrite,
+			expected: "\x1b[1mX\x1b[0m\x1b[31med\x1b[0m text",
+			origLine: []int{1},
+		},
+		{
+			input:    "日本語\rab",
+			policy:   CarriageReturnOverwrite,
+			expected: "ab本語",
+			origLine: []int{1},
+		},
+		{
+			input:    "日本語\ra",
+			policy:   CarriageReturnOverwrite,
+			expected: "a 本語",
+			origLine: []int{1},
+		},
+	}
+
+	for idx, test := range tests {
+		t.Run(fmt.Sprintf("Carriage Return Test %d", idx+1), func(t *testing.T) {
+			wrapper := Wrapper{
+				Limit: 12, TabSize: 4, TrimWhitespace: true, CarriageReturn: test.policy,
+			}
+			wrapped, seq, err := wrapper.Wrap(test.input)
+			assert.Nil(t, err)
+			assert.Equal(t, test.expected, wrapped)
+
+			var origLine []int
+			for _, wrappedLine := range seq.WrappedLines {
+				origLine = append(origLine, wrappedLine.OrigLineNum)
+			}
+			assert.Equal(t, test.origLine, origLine)
+
+			// the lines still span the whole input, including the text
+			// that was overwritten.
+			assert.Equal(t, 0, seq.WrappedLines[0].OrigByteOffset.Start)
+			assert.Equal(t, len(test.input), seq.lastWrappedLine().OrigByteOffset.End)
+
+			measurement, err := wrapper.Measure(test.input, true)
+			assert.Nil(t, err)
+			assert.Equal(t, len(seq.WrappedLines), measurement.Lines)
+		})
+	}
+}
+
+// TestWrapper_PreserveSeparators tests that lines ending at hard breaks
+// keep their original separators, while soft breaks are written as "\n".
+func TestWrapper_PreserveSeparators(t *testing.T) {
+	input := "one two three four\r\nfive\u2028six\rseven\n"
+	wrapper := Wrapper{Limit: 12, TabSize: 4, TrimWhitespace: true, PreserveSeparators: true}
+
+	wrapped, seq, err := wrapper.Wrap(input)
+	assert.Nil(t, err)
+	assert.Equal(t, "one two\nthree four\r\nfive\u2028six\rseven\n", wrapped)
+	assert.Equal(t, 5, len(seq.WrappedLines))
+
+	tokenized, tokenizedSeq, err := wrapper.WrapTokens(wrapper.Tokenize(input))
+	assert.Nil(t, err)
+	assert.Equal(t, wrapped, tokenized)
+	assert.Equal(t, seq, tokenizedSeq)
+
+	// the separators are restored after truncating.
+	wrapper.MaxLines = 3
+	wrapper.Ellipsis = "…"
+	wrapped, seq, err = wrapper.Wrap(input)
+	assert.Nil(t, err)
+	assert.Equal(t, "one two\nthree four\r\nfive…", wrapped)
+	assert.Equal(t, SeparatorNone, seq.lastWrappedLine().Separator)
+
+	wrapper.EllipsisPlacement = EllipsisStart
+	wrapped, _, err = wrapper.Wrap(input)
+	assert.Nil(t, err)
+	assert.Equal(t, "…five\u2028six\rseven\n", wrapped)
+}
+
+// lineBreakTestCase is a struct that contains the input string, the
+// wrapper configuration, and the expected wrapped string and lines.
+type lineBreakTestCase struct {
+	input    string
+	wrapper  Wrapper
+	expected string
+	lines    []string
+}
+
+// TestWrapper_LineBreak tests wrapping with configured line separators,
+// and that the output offsets of each line account for them.
+func TestWrapper_LineBreak(t *testing.T) {
+	defer func(size int) { parallelChunkSize = size }(parallelChunkSize)
+	parallelChunkSize = 4
+
+	tests := []lineBreakTestCase{
+		{
+			input:    "The quick brown fox\njumps over\n",
+			wrapper:  Wrapper{Limit: 10, TabSize: 4, TrimWhitespace: true, LineBreak: "\r\n"},
+			expected: "The quick\r\nbrown fox\r\njumps over\r\n",
+			lines:    []string{"The quick", "brown fox", "jumps over"},
+		},
+		{
+			input:    "The quick brown fox\njumps over",
+			wrapper:  Wrapper{Limit: 10, TabSize: 4, TrimWhitespace: true, LineBreak: "<br>"},
+			expected: "The quick<br>brown fox<br>jumps over",
+			lines:    []string{"The quick", "brown fox", "jumps over"},
+		},
+		{
+			input: "echo one two three four\necho five",
+			wrapper: Wrapper{
+				Limit: 14, TabSize: 4, TrimWhitespace: true, SoftBreak: " \\\n",
+			},
+			expected: "echo one two \\\nthree four\necho five",
+			lines:    []string{"echo one two", "three four", "echo five"},
+		},
+		{
+			input: "one two three\r\nfour",
+			wrapper: Wrapper{
+				Limit: 8, TabSize: 4, TrimWhitespace: true,
+				LineBreak: "<br>", SoftBreak: "<wbr>", PreserveSeparators: true,
+			},
+			expected: "one two<wbr>three\r\nfour",
+			lines:    []string{"one two", "three", "four"},
+		},
+		{
+			input: "one two three four five six",
+			wrapper: Wrapper{
+				Limit: 8, TabSize: 4, TrimWhitespace: true, LineBreak: "\r\n",
+				MaxLines: 2, Ellipsis: "…",
+			},
+			expected: "one two\r\nthree…",
+			lines:    []string{"one two", "three…"},
+		},
+	}
+
+	for idx, test := range tests {
+		t.Run(fmt.Sprintf("Line Break Test %d", idx+1), func(t *testing.T) {
+			wrapped, seq, err := test.wrapper.Wrap(test.input)
+			assert.Nil(t, err)
+			assert.Equal(t, test.expected, wrapped)
+
+			var lines []string
+			for _, wrappedLine := range seq.WrappedLines {
+				offset := wrappedLine.WrappedByteOffset
+				lines = append(lines, wrapped[offset.Start:offset.End])
+			}
+			assert.Equal(t, test.lines, lines)
+
+			tokenized, tokenizedSeq, err := test.wrapper.WrapTokens(test.wrapper.Tokenize(test.input))
+			assert.Nil(t, err)
+			assert.Equal(t, wrapped, tokenized)
+			assert.Equal(t, seq, tokenizedSeq)
+
+			parallel := test.wrapper
+			parallel.Workers = 2
+			parallelWrapped, parallelSeq, err := parallel.Wrap(test.input)
+			assert.Nil(t, err)
+			assert.Equal(t, wrapped, parallelWrapped)
+			assert.Equal(t, seq, parallelSeq)
+		})
+	}
+}
+
+// TestRewrapEdit_LineBreak tests that rewrapping after an edit reuses the
+// separators recorded in the metadata, and shifts the output offsets of
+// the lines after the edit.
+func TestRewrapEdit_LineBreak(t *testing.T) {
+	input := "The quick brown fox\njumps over\nthe lazy dog"
+	wrapper := Wrapper{Limit: 10, TabSize: 4, TrimWhitespace: true, LineBreak: "\r\n", SoftBreak: "<br>"}
+	wrapped, seq, _ := wrapper.Wrap(input)
+
+	for idx, edit := range []Edit{
+		{Start: 4, End: 4, Text: "very "},
+		{Start: 19, End: 20, Text: " "},
+		{Start: 31, End: 31, Text: "dear "},
+	} {
+		t.Run(fmt.Sprintf("Rewrap Edit Line Break Test %d", idx+1), func(t *testing.T) {
+			rewrapped, rewrappedSeq, err := RewrapEdit(input, wrapped, seq, edit)
+			assert.Nil(t, err)
+
+			expected, expectedSeq, _ := wrapper.Wrap(edit.Apply(input))
+			assert.Equal(t, expected, rewrapped)
+			assert.Equal(t, expectedSeq, rewrappedSeq)
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package stringwrap
+
+import (
+	"errors"
+	"strings"
+)
+
+// SMSEncoding is the character encoding an SMS message is sent with.
+type SMSEncoding int
+
+const (
+	// EncodingGSM7 is the GSM 03.38 7-bit default alphabet, in which
+	// each character takes one septet, or two for characters of the
+	// extension table.
+	EncodingGSM7 SMSEncoding = iota
+	// EncodingUCS2 is the 16-bit encoding used when any character is not
+	// in the GSM 7-bit alphabet, in which each character takes one
+	// UTF-16 code unit, or two for characters outside the basic
+	// multilingual plane.
+	EncodingUCS2
+)
+
+const (
+	// gsm7Basic is the GSM 03.38 basic character set, without the escape
+	// to the extension table
+	gsm7Basic = "@£$¥èéùìòÇ\nØø\rÅåΔ_ΦΓΛΩΠΨΣΘΞÆæßÉ !\"#¤%&'()*+,-./0123456789:;<=>?" +
+		"¡ABCDEFGHIJKLMNOPQRSTUVWXYZÄÖÑÜ§¿abcdefghijklmnopqrstuvwxyzäöñüà"
+	// gsm7Extension is the GSM 03.38 extension table, whose characters
+	// are preceded by an escape and so take two septets
+	gsm7Extension = "\f^{}\\[~]|€"
+)
+
+// the number of units in a single SMS segment, and in each segment of a
+// concatenated message, which is shorter to make room for the header
+// that joins the segments
+const (
+	gsm7SingleSegment = 160
+	gsm7MultiSegment  = 153
+	ucs2SingleSegment = 70
+	ucs2MultiSegment  = 67
+)
+
+// SMSSegment is a single segment of an SMS message, along with its cost
+// and its location in the original message.
+type SMSSegment struct {
+	// The text of the segment.
+	Text string
+	// The encoding the segment is sent with, which is the same for
+	// every segment of a message.
+	Encoding SMSEncoding
+	// The number of septets (GSM-7) or code units (UCS-2) the segment
+	// takes.
+	Units int
+	// The byte start and end offsets of this segment in the original
+	// message.
+	OrigByteOffset LineOffset
+	// The rune start and end offsets of this segment in the original
+	// message.
+	OrigRuneOffset LineOffset
+}
+
+// gsm7Length returns the number of septets the string takes in the GSM
+// 7-bit alphabet
+func gsm7Length(str string) int {
+	length := 0
+	for _, r := range str {
+		if strings.ContainsRune(gsm7Extension, r) {
+			length += 2
+		} else {
+			length += 1
+		}
+	}
+	return length
+}
+
+// isGSM7 returns true if every character of the string is in the GSM
+// 7-bit alphabet
+func isGSM7(str string) bool {
+	for _, r := range str {
+		if !strings.ContainsRune(gsm7Basic, r) && !strings.ContainsRune(gsm7Extension, r) {
+			return false
+		}
+	}
+	return true
+}
+
+// SegmentSMS splits a message into the segments it is sent as by SMS.
+//
+// The message is encoded with GSM-7 if every character is in the GSM
+// 7-bit alphabet, and with UCS-2 otherwise. A message that fits in a
+// single segment of 160 septets or 70 code units is sent as one, and
+// longer messages are split into segments of 153 septets or 67 code
+// units.
+//
+// Segments break at word boundaries where possible, and never cut a
+// grapheme cluster or an escaped extension character. Words longer than
+// a segment are split between grapheme clusters. Whitespace and line
+// breaks are kept, so the segments join back into the original message.
+func SegmentSMS(str string) ([]SMSSegment, error) {
+	encoding := EncodingUCS2
+	measurer := MetricUTF16.measurer()
+	limit := ucs2SingleSegment
+	if isGSM7(str) {
+		encoding = EncodingGSM7
+		measurer = WidthFunc(gsm7Length)
+		limit = gsm7SingleSegment
+	}
+
+	// every character is sent as it is, so whitespace, line breaks and
+	// escape sequences are all measured as text and never written as
+	// anything else.
+	tokens := tokenize(str, measurer)
+	units := 0
+	for idx := range tokens {
+		token := &tokens[idx]
+		switch token.Kind {
+		case TokenTab, TokenHardBreak, TokenControl:
+			token.Kind = TokenSpace
+			token.Width = measurer.StringWidth(token.Text)
+		case TokenANSI:
+			token.Width = measurer.StringWidth(token.Text)
+		}
+		units += token.Width
+	}
+
+	if units > limit {
+		limit = gsm7MultiSegment
+		if encoding == EncodingUCS2 {
+			limit = ucs2MultiSegment
+		}
+	}
+
+	config := wordWrapConfig{
+		limit:              limit,
+		splitWord:          true,
+		measurer:           measurer,
+		omitHyphen:         true,
+		splitLongWordsOnly: true,
+	}
+	_, seq, err := wrapTokensFrom(tokens, config, newPositions(1, 1, 0, 0))
+	if err != nil {
+		return nil, err
+	}
+
+	segments := make([]SMSSegment, len(seq.WrappedLines))
+	for idx, wrappedLine := range seq.WrappedLines {
+		if wrappedLine.NotWithinLimit {
+			return nil, errors.New("grapheme cluster is larger than an SMS segment")
+		}
+
+		byteOffset := wrappedLine.OrigByteOffset
+		segments[idx] = SMSSegment{
+			Text:           str[byteOffset.Start:byteOffset.End],
+			Encoding:       encoding,
+			Units:          wrappedLine.Width,
+			OrigByteOffset: byteOffset,
+			OrigRuneOffset: wrappedLine.OrigRuneOffset,
+		}
+	}
+	return segments, nil
+}
@@ -0,0 +1,117 @@
+package stringwrap
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// smsTestCase is a struct that contains the input message, and the
+// expected encoding, units and byte offsets of each segment.
+type smsTestCase struct {
+	input       string
+	encoding    SMSEncoding
+	units       []int
+	byteOffsets []LineOffset
+}
+
+// TestSegmentSMS tests splitting messages into SMS segments with a
+// variety of test cases.
+func TestSegmentSMS(t *testing.T) {
+	tests := []smsTestCase{
+		{
+			input:       strings.Repeat("a", 160),
+			encoding:    EncodingGSM7,
+			units:       []int{160},
+			byteOffsets: []LineOffset{{Start: 0, End: 160}},
+		},
+		{
+			input:       strings.Repeat("a", 161),
+			encoding:    EncodingGSM7,
+			units:       []int{153, 8},
+			byteOffsets: []LineOffset{{Start: 0, End: 153}, {Start: 153, End: 161}},
+		},
+		{
+			input:       strings.Repeat("€", 80),
+			encoding:    EncodingGSM7,
+			units:       []int{160},
+			byteOffsets: []LineOffset{{Start: 0, End: 240}},
+		},
+		{
+			input:       strings.Repeat("€", 81),
+			encoding:    EncodingGSM7,
+			units:       []int{152, 10},
+			byteOffsets: []LineOffset{{Start: 0, End: 228}, {Start: 228, End: 243}},
+		},
+		{
+			input:       strings.Repeat("hello world ", 20),
+			encoding:    EncodingGSM7,
+			units:       []int{150, 90},
+			byteOffsets: []LineOffset{{Start: 0, End: 150}, {Start: 150, End: 240}},
+		},
+		{
+			input:       "Hi {name}\nyour code is 1234",
+			encoding:    EncodingGSM7,
+			units:       []int{29},
+			byteOffsets: []LineOffset{{Start: 0, End: 27}},
+		},
+		{
+			input:       strings.Repeat("日本", 40),
+			encoding:    EncodingUCS2,
+			units:       []int{67, 13},
+			byteOffsets: []LineOffset{{Start: 0, End: 201}, {Start: 201, End: 240}},
+		},
+		{
+			input:       strings.Repeat("👍", 36),
+			encoding:    EncodingUCS2,
+			units:       []int{66, 6},
+			byteOffsets: []LineOffset{{Start: 0, End: 132}, {Start: 132, End: 144}},
+		},
+		{
+			input:       strings.Repeat("word ", 13) + "日本",
+			encoding:    EncodingUCS2,
+			units:       []int{67},
+			byteOffsets: []LineOffset{{Start: 0, End: 71}},
+		},
+	}
+
+	for idx, tt := range tests {
+		t.Run(fmt.Sprintf("SMS Test %d", idx+1), func(t *testing.T) {
+			segments, err := SegmentSMS(tt.input)
+			assert.Nil(t, err)
+
+			var joined strings.Builder
+			units := make([]int, 0, len(segments))
+			byteOffsets := make([]LineOffset, 0, len(segments))
+			for _, segment := range segments {
+				assert.Equal(t, tt.encoding, segment.Encoding)
+				units = append(units, segment.Units)
+				byteOffsets = append(byteOffsets, segment.OrigByteOffset)
+				joined.WriteString(segment.Text)
+			}
+			assert.Equal(t, tt.units, units)
+			assert.Equal(t, tt.byteOffsets, byteOffsets)
+			assert.Equal(t, tt.input, joined.String())
+		})
+	}
+}
+
+// TestSegmentSMS_Edges tests empty messages and grapheme clusters that
+// are larger than a segment.
+func TestSegmentSMS_Edges(t *testing.T) {
+	segments, err := SegmentSMS("")
+	assert.Nil(t, err)
+	assert.Empty(t, segments)
+
+	_, err = SegmentSMS("a" + strings.Repeat("\u0301", 70) + " b")
+	assert.NotNil(t, err)
+
+	segments, err = SegmentSMS("[~]")
+	assert.Nil(t, err)
+	assert.Equal(t, []SMSSegment{{
+		Text: "[~]", Encoding: EncodingGSM7, Units: 6,
+		OrigByteOffset: LineOffset{Start: 0, End: 3}, OrigRuneOffset: LineOffset{Start: 0, End: 3},
+	}}, segments)
+}
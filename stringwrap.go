@@ -3,12 +3,9 @@ package stringwrap
 import (
 	"bytes"
 	"errors"
-	"strings"
 	"unicode"
 	"unicode/utf8"
 
-	"github.com/galactixx/ansiwalker"
-	"github.com/mattn/go-runewidth"
 	"github.com/rivo/uniseg"
 )
 
@@ -19,14 +16,6 @@ func isWordyGrapheme(grapheme string) bool {
 	return unicode.IsLetter(r) || unicode.IsNumber(r)
 }
 
-// btoi is a simple function to convert a boolean to an integer
-func btoi(b bool) int {
-	if b {
-		return 1
-	}
-	return 0
-}
-
 // LineOffset represents a half-open interval [Start, End) that describes
 // either the byte offset or rune offset range of a wrapped segment
 // in the original unwrapped string.
@@ -52,6 +41,9 @@ type WrappedString struct {
 	// The rune start and end offsets of this segment in the
 	// original unwrapped string.
 	OrigRuneOffset LineOffset
+	// The byte start and end offsets of this segment in the
+	// wrapped output, not including the separator after it.
+	WrappedByteOffset LineOffset
 	// Which segment number this is within the original line
 	// (first, second, etc.).
 	SegmentInOrig int
@@ -64,6 +56,9 @@ type WrappedString struct {
 	// Whether the wrap was due to a hard break (newline)
 	// instead of word wrapping.
 	IsHardBreak bool
+	// The line separator of the hard break that ended this
+	// segment, or SeparatorNone if it was not a hard break.
+	Separator LineSeparator
 	// The viewable width of the wrapped string.
 	Width int
 	// Whether this wrapped segment ends with a split word due
@@ -83,8 +78,56 @@ type WrappedStringSeq struct {
 	WordSplitAllowed bool
 	// TabSize defines how many spaces a tab character expands to.
 	TabSize int
+	// TabStops holds the columns of the tab stops before those every
+	// TabSize columns, and KeepTabs indicates whether tabs were written
+	// as tabs rather than spaces.
+	TabStops []int
+	KeepTabs bool
+	// TrimWhitespace indicates whether leading and trailing whitespace
+	// is stripped from each wrapped line.
+	TrimWhitespace bool
 	// Limit is the maximum viewable width allowed per line.
 	Limit int
+	// CustomMeasurer indicates whether widths were measured with the
+	// Measurer of a wrapper rather than go-runewidth, and Metric is the
+	// unit that widths were measured in.
+	CustomMeasurer bool
+	Metric         Metric
+	// WhiteSpace is the whitespace processing mode of the wrapper, and
+	// Reflow indicates whether the lines of each paragraph were joined.
+	WhiteSpace WhiteSpace
+	Reflow     bool
+	// CarriageReturn is how lone carriage returns were handled.
+	CarriageReturn CarriageReturn
+	// LineBreak is written after each wrapped line, where empty means
+	// "\n".
+	LineBreak string
+	// SoftBreak is written after lines that were wrapped rather than
+	// ending at a hard break, where empty means LineBreak.
+	SoftBreak string
+	// PreserveSeparators indicates whether lines that end at a hard
+	// break are written with their original separator instead.
+	PreserveSeparators bool
+	// LeadingMarker is written at the start of lines that continue a
+	// wrapped original line, and TrailingMarker at the end of lines that
+	// are continued on the next.
+	LeadingMarker  string
+	TrailingMarker string
+	// MaxLines, Ellipsis and EllipsisPlacement are the truncation options
+	// of the wrapper, where zero MaxLines means no maximum.
+	MaxLines          int
+	Ellipsis          string
+	EllipsisPlacement EllipsisPlacement
+	// Truncated indicates whether lines were removed to keep within the
+	// maximum number of lines of the wrapper.
+	Truncated bool
+	// The byte start and end offsets of the text in the original string
+	// that was replaced by the ellipsis. Start is where the visible text
+	// ends, and End is where it resumes.
+	TruncatedByteOffset LineOffset
+	// The rune start and end offsets of the text in the original string
+	// that was replaced by the ellipsis.
+	TruncatedRuneOffset LineOffset
 }
 
 // lastWrappedLine pulls the last wrapped line that has been parsed
@@ -106,6 +149,8 @@ type graphemeWordIter struct {
 	nextClusterWidth int
 	cluster          string
 	graphemes        *uniseg.Graphemes
+	measurer         Measurer
+	hyphenWidth      int
 }
 
 // needsHyphen returns true if a hyphen should be added when
@@ -118,14 +163,14 @@ func (g *graphemeWordIter) totalWidth(lineWidth int) int {
 	return g.subWordWidth + lineWidth + g.nextClusterWidth
 }
 
-// iter iterates through the word buffer until the limit
-// is exceeded or the word buffer is empty.
+// iter iterates through the word buffer until the limit would be
+// exceeded, leaving room for a hyphen, or the word buffer is empty.
 func (g *graphemeWordIter) iter(lineWidth int, limit int) {
-	for g.graphemes.Next() && g.totalWidth(lineWidth) < limit {
+	for g.graphemes.Next() && g.totalWidth(lineWidth)+g.hyphenWidth <= limit {
 		g.preLimitCluster = g.cluster
 		g.cluster = g.graphemes.Str()
 		g.subWordWidth += g.nextClusterWidth
-		g.nextClusterWidth = runewidth.StringWidth(g.cluster)
+		g.nextClusterWidth = measureWidth(g.measurer, g.cluster)
 		g.subWordBuffer.WriteString(g.preLimitCluster)
 	}
 }
@@ -142,49 +187,60 @@ func (g *graphemeWordIter) iter(lineWidth int, limit int) {
 // - curLineWidth: Visual width of current line
 // - curLineNum: Current wrapped line number
 // - origLineSegment: Segment number within original line
-// - timmedWhiteSpace: Count of trimmed whitespace
+// - curLineSpaceWidth: Visual width of whitespace ending the current line
 //
 // WORD-LOCAL (reset when word completes):
 // - curWordWidth: Visual width of current word
+// - origWordByte: Byte offset where the buffered word starts
+// - origWordRune: Rune offset where the buffered word starts
 //
 // PERSISTENT (maintained across entire process):
 // - origLineNum: Original unwrapped line number
+// - origStartLineNum: Original line number where the current line started
 // - origStartLineByte: Byte offset where line started
 // - origStartLineRune: Rune offset where line started
+// - origCurByte: Byte offset up to which input has been written to the line
+// - origCurRune: Rune offset up to which input has been written to the line
+// - wrappedByte: Byte offset in the wrapped output where the next line starts
 //
 // Flow: Characters → wordBuffer (curWordWidth) → lineBuffer (curLineWidth) → final output
 type positions struct {
 	curLineWidth      int
 	curLineNum        int
 	origLineNum       int
+	origStartLineNum  int
 	curWordWidth      int
 	origLineSegment   int
 	origStartLineByte int
 	origStartLineRune int
-	timmedWhiteSpace  int
+	origCurByte       int
+	origCurRune       int
+	origWordByte      int
+	origWordRune      int
+	curLineSpaceWidth int
+	wrappedByte       int
 }
 
-// endLineCalc calculates the end byte/rune index
-func (p positions) endCalc(count int, lineCount int, hard bool, split bool) int {
-	origEndLine := count + lineCount - 1 + btoi(hard) - btoi(split)
-	return origEndLine + p.timmedWhiteSpace
+// origOffsets returns the byte and rune offsets spanning from the start
+// of the current line up to the input written to the line so far
+func (p positions) origOffsets() (LineOffset, LineOffset) {
+	return LineOffset{Start: p.origStartLineByte, End: p.origCurByte},
+		LineOffset{Start: p.origStartLineRune, End: p.origCurRune}
 }
 
-// getEndLineByte calculates the end byte index and offset
-func (p positions) endByte(line string, hard bool, split bool) (int, LineOffset) {
-	endLine := p.endCalc(p.origStartLineByte, len(line), hard, split)
-	return endLine, LineOffset{Start: p.origStartLineByte, End: endLine}
+// advanceOrig moves the original position to the end of the token
+func (p *positions) advanceOrig(token Token) {
+	p.origCurByte = token.ByteOffset.End
+	p.origCurRune = token.RuneOffset.End
 }
 
-// getEndLineRune calculates the end rune index and offset
-func (p positions) endRune(line string, hard bool, split bool) (int, LineOffset) {
-	endLine := p.endCalc(
-		p.origStartLineRune,
-		utf8.RuneCountInString(line),
-		hard,
-		split,
-	)
-	return endLine, LineOffset{Start: p.origStartLineRune, End: endLine}
+// advanceOrigWord moves the start of the buffered word, and the original
+// position, past the given prefix of the word
+func (p *positions) advanceOrigWord(prefix []byte) {
+	p.origWordByte += len(prefix)
+	p.origWordRune += utf8.RuneCount(prefix)
+	p.origCurByte = p.origWordByte
+	p.origCurRune = p.origWordRune
 }
 
 // returns the current viewable width (word + line)
@@ -202,6 +258,154 @@ type wordWrapConfig struct {
 	tabSize        int
 	trimWhitespace bool
 	splitWord      bool
+	measurer       Measurer
+	// the width of each column that a tab expands to, where zero is
+	// treated as one
+	columnWidth int
+	// the columns of the tab stops before the uniform ones every tabSize
+	// columns, and whether tabs are written as they are rather than as
+	// spaces
+	tabStops []int
+	keepTabs bool
+	// whether split words are left without a hyphen
+	omitHyphen bool
+	// whether only words wider than the limit are split, rather than
+	// every word that does not fit on the rest of the line
+	splitLongWordsOnly bool
+	// whether runs of spaces and tabs, and hard breaks if collapseBreaks
+	// is set, are written as a single space
+	collapseSpaces bool
+	collapseBreaks bool
+	// whether spaces at the end of a line hang past the limit rather
+	// than wrapping, without counting towards the width of the line
+	hangSpaces bool
+	// whether lines only end at hard breaks
+	noWrap bool
+	// whether hard breaks join the original lines on either side of them
+	// into one, as a single space
+	joinBreaks bool
+	// how a carriage return that is not followed by a line feed is
+	// handled
+	carriageReturn CarriageReturn
+	// the separators written after each line, and after soft-wrapped
+	// lines, where empty means "\n" and lineBreak respectively
+	lineBreak string
+	softBreak string
+	// whether lines that end at a hard break are written with their
+	// original separator
+	preserveSeparators bool
+	// the markers written at the start of lines that continue a wrapped
+	// original line, and at the end of lines that end at a soft break,
+	// along with their widths
+	leadingMarker  string
+	trailingMarker string
+	leadingWidth   int
+	trailingWidth  int
+}
+
+// setMarkers sets the continuation markers, measuring their widths with
+// the measurer of the configuration
+func (c *wordWrapConfig) setMarkers(leading string, trailing string) {
+	c.leadingMarker, c.leadingWidth = leading, cellsWidth(leading, c.measurer)
+	c.trailingMarker, c.trailingWidth = trailing, cellsWidth(trailing, c.measurer)
+}
+
+// continuationLimit returns the width available to the text of a line
+// that continues a wrapped original line
+func (c wordWrapConfig) continuationLimit() int {
+	return c.limit - c.leadingWidth - c.trailingWidth
+}
+
+// separator returns the text written after a line that ended with the
+// given line separator, or SeparatorNone for a soft break
+func (c wordWrapConfig) separator(separator LineSeparator) string {
+	switch {
+	case separator != SeparatorNone && c.preserveSeparators:
+		return separator.Text()
+	case separator == SeparatorNone && c.softBreak != "":
+		return c.softBreak
+	case c.lineBreak != "":
+		return c.lineBreak
+	}
+	return "\n"
+}
+
+// hyphenWidth returns the width of the hyphen written after split words
+func (c wordWrapConfig) hyphenWidth() int {
+	if c.omitHyphen {
+		return 0
+	}
+	return measureWidth(c.measurer, "-")
+}
+
+// column returns the width of each column that a tab expands to
+func (c wordWrapConfig) column() int {
+	if c.columnWidth > 0 {
+		return c.columnWidth
+	}
+	return 1
+}
+
+// tabWidth returns the width of a tab written at the given width of a
+// line, which reaches the next tab stop
+func (c wordWrapConfig) tabWidth(width int) int {
+	column := c.column()
+	last := 0
+	for _, stop := range c.tabStops {
+		if stop*column > width {
+			return stop*column - width
+		}
+		last = stop * column
+	}
+
+	// past the last explicit tab stop, the tab stops continue every
+	// tabSize columns.
+	tabStop := c.tabSize * column
+	if tabStop <= 0 {
+		return 0
+	}
+	return tabStop - (width-last)%tabStop
+}
+
+// widestTab returns the greatest width that a tab can expand to
+func (c wordWrapConfig) widestTab() int {
+	widest, last := c.tabSize, 0
+	for _, stop := range c.tabStops {
+		widest = max(widest, stop-last)
+		last = stop
+	}
+	return widest * c.column()
+}
+
+// validate returns an error if the configuration cannot be wrapped to
+func (c wordWrapConfig) validate() error {
+	if c.limit < 2 {
+		return errors.New("limit must be greater than one")
+	}
+	if c.continuationLimit() < 2 {
+		return errors.New("limit must leave room for the continuation markers")
+	}
+	for i, stop := range c.tabStops {
+		if stop < 1 || (i > 0 && stop <= c.tabStops[i-1]) {
+			return errors.New("tab stops must be positive and increasing")
+		}
+	}
+	return nil
+}
+
+// trailingSpaceWidth returns the viewable width of the whitespace at the
+// end of the string, and whether the string is entirely whitespace
+func trailingSpaceWidth(str []byte, m Measurer) (int, bool) {
+	width := 0
+	for len(str) > 0 {
+		r, size := utf8.DecodeLastRune(str)
+		if !unicode.IsSpace(r) {
+			return width, false
+		}
+		width += measureWidth(m, string(str[len(str)-size:]))
+		str = str[:len(str)-size]
+	}
+	return width, true
 }
 
 // buffer to manage the wrapped output that results from the function and
@@ -216,21 +420,63 @@ type wrapStateMachine struct {
 	wrappedStringSeq *WrappedStringSeq
 	config           wordWrapConfig
 	wordHasNbsp      bool
+	inSpaceRun       bool
+
+	// whether the last line written ended at a soft break, and its
+	// width, along with the widest line written before it
+	softEnd      bool
+	lastWidth    int
+	prevMaxWidth int
+
+	// the line that a carriage return moved back over, which shows
+	// through past the end of the text written over it
+	overwritten      string
+	overwrittenWidth int
+
+	// when measuring, lines are recorded in the measurement instead of
+	// being written to the buffer and the wrapped string sequence
+	measurement *Measurement
+	lineWidths  bool
+}
+
+// newStateMachine creates a state machine that wraps using the given
+// configuration, starting from the given positional state
+func newStateMachine(config wordWrapConfig, positions positions) *wrapStateMachine {
+	return &wrapStateMachine{
+		pos: &positions,
+		wrappedStringSeq: &WrappedStringSeq{
+			WordSplitAllowed:   config.splitWord,
+			TabSize:            config.tabSize,
+			TabStops:           config.tabStops,
+			KeepTabs:           config.keepTabs,
+			TrimWhitespace:     config.trimWhitespace,
+			Limit:              config.limit,
+			LineBreak:          config.lineBreak,
+			SoftBreak:          config.softBreak,
+			PreserveSeparators: config.preserveSeparators,
+			LeadingMarker:      config.leadingMarker,
+			TrailingMarker:     config.trailingMarker,
+		},
+		config: config,
+	}
 }
 
 // writeANSIToLine writes ANSI to the line buffer
 func (w *wrapStateMachine) writeANSIToLine(str string) {
 	w.lineBuffer.WriteString(str)
+	w.pos.curLineSpaceWidth = 0
 }
 
-// writeRuneToLine appends the given string directly to the lineBuffer.
-func (w *wrapStateMachine) writeSpaceToLine(r rune) {
-	w.flushLineBuffer(1)
+// writeSpaceToLine appends the given whitespace rune directly to the
+// lineBuffer.
+func (w *wrapStateMachine) writeSpaceToLine(r rune, width int) {
+	if !w.config.hangSpaces {
+		w.flushLineBuffer(width)
+	}
 	if !w.config.trimWhitespace || w.pos.curLineWidth > 0 {
 		w.lineBuffer.WriteRune(r)
-		w.pos.curLineWidth += 1
-	} else {
-		w.pos.timmedWhiteSpace += 1
+		w.pos.curLineWidth += width
+		w.pos.curLineSpaceWidth += width
 	}
 }
 
@@ -244,13 +490,10 @@ func (w *wrapStateMachine) writeRuneToWord(r rune) {
 	w.wordBuffer.WriteRune(r)
 }
 
-// writeTabToLine appends the given tab size in spaces to the lineBuffer.
-func (w *wrapStateMachine) writeTabToLine() int {
-	var adjTabSize = 0
-
-	if w.config.tabSize > 0 {
-		adjTabSize = w.config.tabSize - (w.pos.curLineWidth % w.config.tabSize)
-	}
+// writeTabToLine appends the tab, expanded to the next tab stop, to the
+// lineBuffer.
+func (w *wrapStateMachine) writeTabToLine() {
+	adjTabSize := w.config.tabWidth(w.pos.curLineWidth)
 	w.flushLineBuffer(adjTabSize)
 
 	// if the line buffer is empty, adjust the tab size based on the
@@ -258,73 +501,155 @@ func (w *wrapStateMachine) writeTabToLine() int {
 	if w.lineBuffer.Len() == 0 {
 		if w.config.trimWhitespace {
 			adjTabSize = 0
-			w.pos.timmedWhiteSpace += 1
 		} else {
-			adjTabSize = w.config.tabSize
+			adjTabSize = w.config.tabWidth(0)
 		}
 	}
 
-	tabSpaces := strings.Repeat(" ", adjTabSize)
-	w.lineBuffer.WriteString(tabSpaces)
-	return adjTabSize
+	// the tab is written as the number of columns nearest to its width,
+	// which is exact unless columns have a fractional width, or as a
+	// tab that is measured as that width.
+	column := w.config.column()
+	if w.config.keepTabs {
+		if adjTabSize > 0 {
+			w.lineBuffer.WriteByte('\t')
+		}
+	} else {
+		for i := 0; i < (adjTabSize+column/2)/column; i++ {
+			w.lineBuffer.WriteByte(' ')
+		}
+	}
+	w.pos.curLineWidth += adjTabSize
+	w.pos.curLineSpaceWidth += adjTabSize
 }
 
 // writeHardLine is used to write a hard break
-func (w *wrapStateMachine) writeHardLine() { w.writeLine(true, false) }
+func (w *wrapStateMachine) writeHardLine(separator LineSeparator) {
+	w.writeLine(separator, false)
+}
 
 // writeSoftLine is used to write a soft break
 func (w *wrapStateMachine) writeSoftLine(endsSplit bool) {
-	w.writeLine(false, endsSplit)
+	w.writeLine(SeparatorNone, endsSplit)
 }
 
 // writeLine writes the current lineBuffer to the buffer with a
 // newline, then resets it.
-func (w *wrapStateMachine) writeLine(hardBreak bool, endsSplit bool) {
-	newLine := w.lineBuffer.String()
+func (w *wrapStateMachine) writeLine(separator LineSeparator, endsSplit bool) {
+	hardBreak := separator != SeparatorNone
+	continuation := w.pos.origLineSegment > 0
+	w.composeOverwritten()
+	if w.config.trimWhitespace || w.config.hangSpaces {
+		w.pos.curLineWidth -= w.pos.curLineSpaceWidth
+	}
+	w.pos.origLineSegment += 1
+
+	// the line spans the input written to it since the previous line
+	origByteOffset, origRuneOffset := w.pos.origOffsets()
+	newLine := w.lineBuffer.Bytes()
 	if w.config.trimWhitespace {
-		newLine = strings.TrimRightFunc(newLine, unicode.IsSpace)
-		trimWidth := runewidth.StringWidth(newLine)
-		w.pos.timmedWhiteSpace += w.pos.curLineWidth - trimWidth
-		w.pos.curLineWidth = trimWidth
+		newLine = bytes.TrimRightFunc(newLine, unicode.IsSpace)
 	}
-	newLine += "\n"
 
-	// write the new line to the buffer and reset the line buffer.
-	w.buffer.WriteString(newLine)
-	w.pos.origLineSegment += 1
+	// lines that continue a wrapped original line, and lines that are
+	// continued on the next, are marked as such.
+	var leading, trailing string
+	width := w.pos.curLineWidth
+	if continuation {
+		leading = w.config.leadingMarker
+		width += w.config.leadingWidth
+	}
+	if !hardBreak {
+		trailing = w.config.trailingMarker
+		width += w.config.trailingWidth
+	}
+	w.softEnd, w.lastWidth = !hardBreak, width
+	wrappedByteOffset := LineOffset{
+		Start: w.pos.wrappedByte,
+		End:   w.pos.wrappedByte + len(leading) + len(newLine) + len(trailing),
+	}
+	w.pos.wrappedByte = wrappedByteOffset.End + len(w.config.separator(separator))
+
+	if w.measurement != nil {
+		// record the line in the measurement without building any
+		// output.
+		w.measurement.Lines += 1
+		w.prevMaxWidth = w.measurement.MaxWidth
+		w.measurement.MaxWidth = max(w.measurement.MaxWidth, width)
+		if w.lineWidths {
+			w.measurement.Widths = append(w.measurement.Widths, width)
+		}
+	} else {
+		// write the new line to the buffer.
+		w.buffer.WriteString(leading)
+		w.buffer.Write(newLine)
+		w.buffer.WriteString(trailing)
+		w.buffer.WriteString(w.config.separator(separator))
+
+		// create a new wrapped string and add it to the sequence
+		wrappedString := WrappedString{
+			OrigLineNum:       w.pos.origStartLineNum,
+			CurLineNum:        w.pos.curLineNum,
+			OrigByteOffset:    origByteOffset,
+			OrigRuneOffset:    origRuneOffset,
+			WrappedByteOffset: wrappedByteOffset,
+			SegmentInOrig:     w.pos.origLineSegment,
+			LastSegmentInOrig: hardBreak,
+			NotWithinLimit:    width > w.config.limit,
+			IsHardBreak:       hardBreak,
+			Separator:         separator,
+			Width:             width,
+			EndsWithSplitWord: endsSplit,
+		}
+		w.wrappedStringSeq.appendWrappedSeq(wrappedString)
+	}
 	w.lineBuffer.Reset()
-
-	// calculate the original end line byte and rune offsets
-	origEndLineByte, origByteOffset := w.pos.endByte(newLine, hardBreak, endsSplit)
-	origEndLineRune, origRuneOffset := w.pos.endRune(newLine, hardBreak, endsSplit)
-
-	// create a new wrapped string and add it to the sequence
-	wrappedString := WrappedString{
-		OrigLineNum:       w.pos.origLineNum,
-		CurLineNum:        w.pos.curLineNum,
-		OrigByteOffset:    origByteOffset,
-		OrigRuneOffset:    origRuneOffset,
-		SegmentInOrig:     w.pos.origLineSegment,
-		LastSegmentInOrig: hardBreak,
-		NotWithinLimit:    w.pos.curLineWidth > w.config.limit,
-		IsHardBreak:       hardBreak,
-		Width:             w.pos.curLineWidth,
-		EndsWithSplitWord: endsSplit,
-	}
-	w.wrappedStringSeq.appendWrappedSeq(wrappedString)
 	w.pos.incrementCurLine()
-	w.pos.origStartLineByte = origEndLineByte
-	w.pos.origStartLineRune = origEndLineRune
+	w.pos.origStartLineByte = w.pos.origCurByte
+	w.pos.origStartLineRune = w.pos.origCurRune
+
+	// a line that ended after joining original lines leaves the next one
+	// starting on a later original line.
+	if w.pos.origStartLineNum != w.pos.origLineNum {
+		w.pos.origStartLineNum = w.pos.origLineNum
+		w.pos.origLineSegment = 0
+	}
 
 	// since coming to end of a line, reset char counter to zero
 	w.pos.curLineWidth = 0
-	w.pos.timmedWhiteSpace = 0
+	w.pos.curLineSpaceWidth = 0
+	w.overwritten, w.overwrittenWidth = "", 0
+}
+
+// lineLimit returns the width available to the text of the current line,
+// leaving room for the continuation markers
+func (w *wrapStateMachine) lineLimit() int {
+	if w.pos.origLineSegment > 0 {
+		return w.config.continuationLimit()
+	}
+	return w.config.limit - w.config.trailingWidth
+}
+
+// writeToLine appends a word, or part of a word, to the lineBuffer and
+// moves past it in the original string.
+func (w *wrapStateMachine) writeToLine(word []byte) {
+	w.lineBuffer.Write(word)
+	w.pos.advanceOrigWord(word)
+
+	spaceWidth, allSpace := trailingSpaceWidth(word, w.config.measurer)
+	if allSpace {
+		w.pos.curLineSpaceWidth += spaceWidth
+	} else {
+		w.pos.curLineSpaceWidth = spaceWidth
+	}
 }
 
 // writeWord moves the contents of the wordBuffer into the lineBuffer,
 // then resets the wordBuffer.
 func (w *wrapStateMachine) writeWord() {
-	w.lineBuffer.WriteString(w.wordBuffer.String())
+	if w.wordBuffer.Len() > 0 {
+		w.writeToLine(w.wordBuffer.Bytes())
+	}
 	w.wordBuffer.Reset()
 	w.pos.curLineWidth += w.pos.curWordWidth
 	w.pos.curWordWidth = 0
@@ -333,16 +658,19 @@ func (w *wrapStateMachine) writeWord() {
 // flushLineBuffer writes the current line if adding the next content
 // would exceed the wrapping limit.
 func (w *wrapStateMachine) flushLineBuffer(length int) {
-	if w.pos.curLineWidth+length > w.config.limit {
+	if !w.config.noWrap && w.pos.curLineWidth+length > w.lineLimit() {
 		w.writeSoftLine(false)
 	}
 }
 
 // flushes the word buffer when a word has been written
 func (w *wrapStateMachine) flushWordBuffer() {
-	exceedsLimit := w.pos.curWritePosition() > w.config.limit
+	exceedsLimit := !w.config.noWrap && w.pos.curWritePosition() > w.lineLimit()
 	if exceedsLimit && w.pos.curWordWidth == 0 {
-		w.writeSoftLine(false)
+		// hanging spaces may overflow the limit without wrapping.
+		if !w.config.hangSpaces {
+			w.writeSoftLine(false)
+		}
 		return
 	}
 
@@ -350,16 +678,28 @@ func (w *wrapStateMachine) flushWordBuffer() {
 		// if word splitting is allowed and the word does not contain a
 		// non-breaking space, split the word into graphemes and write
 		// the graphemes to the line buffer.
-		if w.config.splitWord && !w.wordHasNbsp {
+		fitsNextLine := w.config.splitLongWordsOnly &&
+			w.pos.curWordWidth <= w.config.continuationLimit()
+		if w.config.splitWord && !w.wordHasNbsp && !fitsNextLine {
 			gIter := graphemeWordIter{
-				graphemes: uniseg.NewGraphemes(w.wordBuffer.String()),
+				graphemes:   uniseg.NewGraphemes(w.wordBuffer.String()),
+				measurer:    w.config.measurer,
+				hyphenWidth: w.config.hyphenWidth(),
 			}
-			gIter.iter(w.pos.curLineWidth, w.config.limit)
+			gIter.iter(w.pos.curLineWidth, w.lineLimit())
 
-			w.lineBuffer.WriteString(gIter.subWordBuffer.String())
-			if gIter.needsHyphen() {
+			// a grapheme that is wider than the limit on its own cannot
+			// be split any further, so it is written to its own line.
+			if gIter.subWordBuffer.Len() == 0 && w.pos.curLineWidth == 0 {
+				gIter.subWordBuffer.WriteString(gIter.cluster)
+				gIter.subWordWidth = gIter.nextClusterWidth
+			}
+
+			w.writeToLine(gIter.subWordBuffer.Bytes())
+			if gIter.needsHyphen() && !w.config.omitHyphen {
 				w.lineBuffer.WriteRune('-')
-				w.pos.curLineWidth += 1
+				w.pos.curLineWidth += gIter.hyphenWidth
+				w.pos.curLineSpaceWidth = 0
 			}
 
 			// write the graphemes to the line buffer and increment the
@@ -381,119 +721,253 @@ func (w *wrapStateMachine) flushWordBuffer() {
 	w.wordHasNbsp = false
 }
 
-// general function that implements the core string wrap logic
-func stringWrap(
-	str string, limit int, tabSize int, trimWhitespace bool, splitWord bool,
-) (string, *WrappedStringSeq, error) {
-	if limit < 2 {
-		return "", nil, errors.New("limit must be greater than one")
+// collapses returns true if the token is whitespace that is collapsed
+// into a single space with the whitespace around it, which includes the
+// whitespace after a joined break
+func (w *wrapStateMachine) collapses(token Token) bool {
+	if !w.config.collapseSpaces && !w.inSpaceRun {
+		return false
 	}
-
-	// initialize the wrapped string sequence and set the configuration
-	// for the wrapping process.
-	wrappedStringSeq := WrappedStringSeq{
-		WordSplitAllowed: splitWord,
-		TabSize:          tabSize,
-		Limit:            limit,
+	switch token.Kind {
+	case TokenSpace:
+		return token.Text == " "
+	case TokenTab:
+		return true
+	case TokenHardBreak:
+		return w.config.collapseBreaks
 	}
+	return false
+}
 
-	// manage the current string line number taking into account wrapping
-	positions := positions{
-		curLineNum:  1,
-		origLineNum: 1,
+// writeJoinedBreak writes a hard break that joins the original lines on
+// either side of it as a single space, replacing the whitespace at the
+// end of the first line and the start of the second
+func (w *wrapStateMachine) writeJoinedBreak(token Token) {
+	w.flushWordBuffer()
+	trimmed := bytes.TrimRightFunc(w.lineBuffer.Bytes(), unicode.IsSpace)
+	w.lineBuffer.Truncate(len(trimmed))
+	w.pos.curLineWidth -= w.pos.curLineSpaceWidth
+	w.pos.curLineSpaceWidth = 0
+
+	// the space belongs to the second line, so a wrap at it starts the
+	// next wrapped line there.
+	w.pos.incrementOrigLine()
+	w.writeSpaceToLine(' ', measureWidth(w.config.measurer, " "))
+	w.pos.advanceOrig(token)
+	w.inSpaceRun = true
+}
+
+// composeOverwritten writes the columns of the overwritten line that the
+// text after the carriage return did not reach to the end of the line
+func (w *wrapStateMachine) composeOverwritten() {
+	if w.overwrittenWidth > w.pos.curLineWidth {
+		rest := sliceCells(
+			w.overwritten, w.config.measurer,
+			w.pos.curLineWidth, w.overwrittenWidth-w.pos.curLineWidth,
+		)
+		w.lineBuffer.WriteString(rest)
+
+		spaceWidth, allSpace := trailingSpaceWidth([]byte(rest), w.config.measurer)
+		if allSpace {
+			w.pos.curLineSpaceWidth += spaceWidth
+		} else {
+			w.pos.curLineSpaceWidth = spaceWidth
+		}
+		w.pos.curLineWidth = w.overwrittenWidth
 	}
+	w.overwritten, w.overwrittenWidth = "", 0
+}
 
-	// buffer to manage the wrapped output that results from the function
-	stateMachine := wrapStateMachine{
-		pos:              &positions,
-		wrappedStringSeq: &wrappedStringSeq,
-		config: wordWrapConfig{
-			limit:          limit,
-			tabSize:        tabSize,
-			trimWhitespace: trimWhitespace,
-			splitWord:      splitWord,
-		},
+// writeCarriageReturn moves back to the start of the current line, so
+// the text after the carriage return is written over it
+func (w *wrapStateMachine) writeCarriageReturn(token Token) {
+	w.flushWordBuffer()
+	w.composeOverwritten()
+	w.overwritten, w.overwrittenWidth = w.lineBuffer.String(), w.pos.curLineWidth
+	w.lineBuffer.Reset()
+	w.pos.curLineWidth = 0
+	w.pos.curLineSpaceWidth = 0
+	w.pos.advanceOrig(token)
+}
+
+// writeCollapsedSpace writes a single space for a run of collapsible
+// whitespace, moving past the rest of the run in the original string
+// without writing it
+func (w *wrapStateMachine) writeCollapsedSpace(token Token) {
+	if !w.inSpaceRun {
+		w.flushWordBuffer()
+		w.writeSpaceToLine(' ', measureWidth(w.config.measurer, " "))
+		w.inSpaceRun = true
 	}
+	w.pos.advanceOrig(token)
+}
 
-	state := -1
-	idx := 0
+// writeToken feeds a single token of the string to the state machine
+func (w *wrapStateMachine) writeToken(token Token) {
+	if w.collapses(token) {
+		w.writeCollapsedSpace(token)
+		return
+	}
+	if token.Kind == TokenHardBreak && token.Text == "\r" {
+		switch w.config.carriageReturn {
+		case CarriageReturnIgnore:
+			token.Kind = TokenControl
+		case CarriageReturnOverwrite:
+			w.inSpaceRun = false
+			w.writeCarriageReturn(token)
+			return
+		}
+	}
+	if token.Kind == TokenHardBreak && w.config.joinBreaks {
+		w.writeJoinedBreak(token)
+		return
+	}
 
-	// iterate through each rune in the string
-	for idx < len(str) {
-		r, rSize, next, ok := ansiwalker.ANSIWalk(str, idx)
-		rIdx := next - rSize
-		if ok && rIdx > idx {
-			stateMachine.flushWordBuffer()
-			stateMachine.writeANSIToLine(str[idx:rIdx])
-			state = -1
+	// escape sequences and controls are not written as text, so the
+	// whitespace on either side of them is still a single run.
+	if token.Kind != TokenANSI && token.Kind != TokenControl {
+		w.inSpaceRun = false
+	}
+	switch token.Kind {
+	case TokenANSI:
+		w.flushWordBuffer()
+		// escape sequences only have a width when counting the encoded
+		// length of the line, rather than its viewable width.
+		if token.Width > 0 {
+			w.flushLineBuffer(token.Width)
 		}
-		idx = rIdx
-
-		// handle the different types of runes in the string
-		switch {
-		case r == '\u00A0':
-			stateMachine.wordHasNbsp = true
-			stateMachine.writeRuneToWord(r)
-			positions.curWordWidth += 1
-			idx += rSize
-		case unicode.IsSpace(r):
-			stateMachine.flushWordBuffer()
-
-			// Handle the different types of whitespace characters
-			// in the string (e.g., space, newline, tab, etc.).
-			switch r {
-			case ' ':
-				stateMachine.writeSpaceToLine(r)
-			case '\n', '\r', '\u0085', '\u2028', '\u2029':
-				stateMachine.writeHardLine()
-				positions.incrementOrigLine()
-				positions.origLineSegment = 0
-			case '\t':
-				adjTabSize := stateMachine.writeTabToLine()
-				positions.curLineWidth += adjTabSize
-			case '\v', '\f':
-				/* ignore */
-			default:
-				stateMachine.writeSpaceToLine(r)
-				positions.curLineWidth += runewidth.RuneWidth(r) - 1
-			}
-			state = -1
-			idx += rSize
-		default:
-			// Step through the string one grapheme at a time.
-			cluster, _, _, st := uniseg.StepString(str[idx:], state)
-			state = st
-
-			// If the cluster is not empty, write the cluster to the word buffer
-			// and increment the word width.
-			if cluster != "" {
-				clusterWidth := runewidth.StringWidth(cluster)
-				positions.curWordWidth += clusterWidth
-
-				// Writer cluster string to word and then check word buffer
-				stateMachine.writeStrToWord(cluster)
-				idx += len(cluster)
-			} else {
-				idx += rSize
-			}
+		w.writeANSIToLine(token.Text)
+		w.pos.curLineWidth += token.Width
+		w.pos.advanceOrig(token)
+	case TokenWord:
+		// write the word to the word buffer and increment the
+		// word width.
+		if w.wordBuffer.Len() == 0 {
+			w.pos.origWordByte = token.ByteOffset.Start
+			w.pos.origWordRune = token.RuneOffset.Start
 		}
+		w.wordHasNbsp = w.wordHasNbsp || token.HasNbsp
+		w.writeStrToWord(token.Text)
+		w.pos.curWordWidth += token.Width
+	case TokenSpace:
+		w.flushWordBuffer()
+		r, _ := utf8.DecodeRuneInString(token.Text)
+		w.writeSpaceToLine(r, token.Width)
+		w.pos.advanceOrig(token)
+	case TokenHardBreak:
+		w.flushWordBuffer()
+		w.pos.advanceOrig(token)
+		w.writeHardLine(separatorKind(token.Text))
+		w.pos.incrementOrigLine()
+		w.pos.origStartLineNum = w.pos.origLineNum
+		w.pos.origLineSegment = 0
+	case TokenTab:
+		w.flushWordBuffer()
+		w.writeTabToLine()
+		w.pos.advanceOrig(token)
+	case TokenControl:
+		w.flushWordBuffer()
+		w.pos.advanceOrig(token)
 	}
+}
 
-	// write word and line buffers after iteration is done
+// finish writes the word and line buffers once every token has been
+// written to the state machine
+func (w *wrapStateMachine) finish() {
 	// if the word buffer is not empty, write the word to the line buffer.
-	stateMachine.flushWordBuffer()
-	if stateMachine.lineBuffer.Len() > 0 {
-		stateMachine.writeSoftLine(false)
+	w.flushWordBuffer()
+	if w.lineBuffer.Len() > 0 {
+		w.writeSoftLine(false)
+	}
+
+	// whitespace and controls after the last line are trimmed rather than
+	// written, but the last line still spans them in the original string.
+	if w.measurement == nil && len(w.wrappedStringSeq.WrappedLines) > 0 {
+		lastWrappedLine := w.wrappedStringSeq.lastWrappedLine()
+		lastWrappedLine.OrigByteOffset.End = w.pos.origCurByte
+		lastWrappedLine.OrigRuneOffset.End = w.pos.origCurRune
+	}
+
+	// remove the last separator, and the trailing marker, from the
+	// wrapped buffer if the last line is not a hard break.
+	if !w.softEnd {
+		return
+	}
+	trailing := w.config.trailingMarker + w.config.separator(SeparatorNone)
+	width := w.lastWidth - w.config.trailingWidth
+	w.pos.wrappedByte -= len(trailing)
+	if w.measurement != nil {
+		w.measurement.MaxWidth = max(w.prevMaxWidth, width)
+		if w.lineWidths {
+			w.measurement.Widths[len(w.measurement.Widths)-1] = width
+		}
+		return
+	}
+	w.buffer.Truncate(w.buffer.Len() - len(trailing))
+	lastWrappedLine := w.wrappedStringSeq.lastWrappedLine()
+	lastWrappedLine.WrappedByteOffset.End -= len(w.config.trailingMarker)
+	lastWrappedLine.Width = width
+	lastWrappedLine.NotWithinLimit = width > w.config.limit
+	lastWrappedLine.LastSegmentInOrig = true
+}
+
+// general function that implements the core string wrap logic
+func stringWrap(
+	str string, limit int, tabSize int, trimWhitespace bool, splitWord bool,
+) (string, *WrappedStringSeq, error) {
+	wrapper := Wrapper{
+		Limit:          limit,
+		TabSize:        tabSize,
+		TrimWhitespace: trimWhitespace,
+		SplitWord:      splitWord,
+	}
+	return wrapper.Wrap(str)
+}
+
+// wrapTokens wraps a pre-tokenized string starting from the first line
+func wrapTokens(
+	tokens []Token, limit int, tabSize int, trimWhitespace bool, splitWord bool,
+) (string, *WrappedStringSeq, error) {
+	config := wordWrapConfig{
+		limit:          limit,
+		tabSize:        tabSize,
+		trimWhitespace: trimWhitespace,
+		splitWord:      splitWord,
+	}
+	return wrapTokensFrom(tokens, config, newPositions(1, 1, 0, 0))
+}
+
+// newPositions creates the positional state for wrapping text that
+// starts at the given line numbers and original offsets
+func newPositions(curLineNum int, origLineNum int, byteIdx int, runeIdx int) positions {
+	return positions{
+		curLineNum:        curLineNum,
+		origLineNum:       origLineNum,
+		origStartLineNum:  origLineNum,
+		origStartLineByte: byteIdx,
+		origStartLineRune: runeIdx,
+		origCurByte:       byteIdx,
+		origCurRune:       runeIdx,
+	}
+}
+
+// wrapTokensFrom runs the wrapping state machine over a pre-tokenized
+// string, starting from the given positional state
+func wrapTokensFrom(
+	tokens []Token, config wordWrapConfig, positions positions,
+) (string, *WrappedStringSeq, error) {
+	if err := config.validate(); err != nil {
+		return "", nil, err
 	}
 
-	// remove the last new line from the wrapped buffer
-	// if the last line is not a hard break.
-	lastWrappedLine := wrappedStringSeq.lastWrappedLine()
-	if !lastWrappedLine.IsHardBreak {
-		stateMachine.buffer.Truncate(stateMachine.buffer.Len() - 1)
-		lastWrappedLine.LastSegmentInOrig = true
+	// iterate through each token in the string, then write whatever is
+	// left in the word and line buffers
+	stateMachine := newStateMachine(config, positions)
+	for _, token := range tokens {
+		stateMachine.writeToken(token)
 	}
-	return stateMachine.buffer.String(), &wrappedStringSeq, nil
+	stateMachine.finish()
+	return stateMachine.buffer.String(), stateMachine.wrappedStringSeq, nil
 }
 
 // StringWrap wraps the input string to the specified viewable-width limit,
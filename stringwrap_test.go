@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 	"testing"
+	"unicode/utf8"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -129,6 +130,27 @@ func TestStringWrap(t *testing.T) {
 			trimWhitespace: true,
 			splitWord:      true,
 		},
+		{
+			input:          "🌟stars",
+			wrapped:        "🌟\ns-\nt-\na-\nrs",
+			limit:          2,
+			trimWhitespace: true,
+			splitWord:      true,
+		},
+		{
+			input:          "\x1b[31mred text\x1b[0m",
+			wrapped:        "\x1b[31mred\ntext\x1b[0m",
+			limit:          5,
+			trimWhitespace: true,
+			splitWord:      false,
+		},
+		{
+			input:          "\x1b[31m\x1b[1mabc def",
+			wrapped:        "\x1b[31m\x1b[1mabc def",
+			limit:          7,
+			trimWhitespace: true,
+			splitWord:      false,
+		},
 	}
 
 	for idx, tt := range tests {
@@ -159,6 +181,7 @@ func TestStringWrap_WrappedStringSeq(t *testing.T) {
 			OrigLineNum:       1,
 			OrigByteOffset:    LineOffset{Start: 0, End: 6},
 			OrigRuneOffset:    LineOffset{Start: 0, End: 6},
+			WrappedByteOffset: LineOffset{Start: 0, End: 5},
 			SegmentInOrig:     1,
 			LastSegmentInOrig: false,
 			NotWithinLimit:    false,
@@ -171,10 +194,12 @@ func TestStringWrap_WrappedStringSeq(t *testing.T) {
 			OrigLineNum:       1,
 			OrigByteOffset:    LineOffset{Start: 6, End: 13},
 			OrigRuneOffset:    LineOffset{Start: 6, End: 13},
+			WrappedByteOffset: LineOffset{Start: 6, End: 12},
 			SegmentInOrig:     2,
 			LastSegmentInOrig: true,
 			NotWithinLimit:    false,
 			IsHardBreak:       true,
+			Separator:         SeparatorLF,
 			Width:             6,
 			EndsWithSplitWord: false,
 		},
@@ -183,6 +208,7 @@ func TestStringWrap_WrappedStringSeq(t *testing.T) {
 			OrigLineNum:       2,
 			OrigByteOffset:    LineOffset{Start: 13, End: 21},
 			OrigRuneOffset:    LineOffset{Start: 13, End: 21},
+			WrappedByteOffset: LineOffset{Start: 13, End: 21},
 			SegmentInOrig:     1,
 			LastSegmentInOrig: false,
 			NotWithinLimit:    false,
@@ -195,6 +221,7 @@ func TestStringWrap_WrappedStringSeq(t *testing.T) {
 			OrigLineNum:       2,
 			OrigByteOffset:    LineOffset{Start: 21, End: 27},
 			OrigRuneOffset:    LineOffset{Start: 21, End: 27},
+			WrappedByteOffset: LineOffset{Start: 22, End: 26},
 			SegmentInOrig:     2,
 			LastSegmentInOrig: false,
 			NotWithinLimit:    false,
@@ -207,10 +234,12 @@ func TestStringWrap_WrappedStringSeq(t *testing.T) {
 			OrigLineNum:       2,
 			OrigByteOffset:    LineOffset{Start: 27, End: 37},
 			OrigRuneOffset:    LineOffset{Start: 27, End: 34},
+			WrappedByteOffset: LineOffset{Start: 27, End: 36},
 			SegmentInOrig:     3,
 			LastSegmentInOrig: true,
 			NotWithinLimit:    false,
 			IsHardBreak:       true,
+			Separator:         SeparatorLF,
 			Width:             7,
 			EndsWithSplitWord: false,
 		},
@@ -219,6 +248,7 @@ func TestStringWrap_WrappedStringSeq(t *testing.T) {
 			OrigLineNum:       3,
 			OrigByteOffset:    LineOffset{Start: 37, End: 42},
 			OrigRuneOffset:    LineOffset{Start: 34, End: 39},
+			WrappedByteOffset: LineOffset{Start: 37, End: 42},
 			SegmentInOrig:     1,
 			LastSegmentInOrig: true,
 			NotWithinLimit:    false,
@@ -258,6 +288,7 @@ func TestStringWrapSplit_WrappedStringSeq(t *testing.T) {
 			OrigLineNum:       1,
 			OrigByteOffset:    LineOffset{Start: 0, End: 9},
 			OrigRuneOffset:    LineOffset{Start: 0, End: 9},
+			WrappedByteOffset: LineOffset{Start: 0, End: 10},
 			SegmentInOrig:     1,
 			LastSegmentInOrig: false,
 			NotWithinLimit:    false,
@@ -270,6 +301,7 @@ func TestStringWrapSplit_WrappedStringSeq(t *testing.T) {
 			OrigLineNum:       1,
 			OrigByteOffset:    LineOffset{Start: 9, End: 18},
 			OrigRuneOffset:    LineOffset{Start: 9, End: 18},
+			WrappedByteOffset: LineOffset{Start: 11, End: 21},
 			SegmentInOrig:     2,
 			LastSegmentInOrig: false,
 			NotWithinLimit:    false,
@@ -282,6 +314,7 @@ func TestStringWrapSplit_WrappedStringSeq(t *testing.T) {
 			OrigLineNum:       1,
 			OrigByteOffset:    LineOffset{Start: 18, End: 27},
 			OrigRuneOffset:    LineOffset{Start: 18, End: 27},
+			WrappedByteOffset: LineOffset{Start: 22, End: 32},
 			SegmentInOrig:     3,
 			LastSegmentInOrig: false,
 			NotWithinLimit:    false,
@@ -294,6 +327,7 @@ func TestStringWrapSplit_WrappedStringSeq(t *testing.T) {
 			OrigLineNum:       1,
 			OrigByteOffset:    LineOffset{Start: 27, End: 37},
 			OrigRuneOffset:    LineOffset{Start: 27, End: 37},
+			WrappedByteOffset: LineOffset{Start: 33, End: 43},
 			SegmentInOrig:     4,
 			LastSegmentInOrig: false,
 			NotWithinLimit:    false,
@@ -306,6 +340,7 @@ func TestStringWrapSplit_WrappedStringSeq(t *testing.T) {
 			OrigLineNum:       1,
 			OrigByteOffset:    LineOffset{Start: 37, End: 47},
 			OrigRuneOffset:    LineOffset{Start: 37, End: 47},
+			WrappedByteOffset: LineOffset{Start: 44, End: 54},
 			SegmentInOrig:     5,
 			LastSegmentInOrig: false,
 			NotWithinLimit:    false,
@@ -318,6 +353,7 @@ func TestStringWrapSplit_WrappedStringSeq(t *testing.T) {
 			OrigLineNum:       1,
 			OrigByteOffset:    LineOffset{Start: 47, End: 56},
 			OrigRuneOffset:    LineOffset{Start: 47, End: 56},
+			WrappedByteOffset: LineOffset{Start: 55, End: 63},
 			SegmentInOrig:     6,
 			LastSegmentInOrig: false,
 			NotWithinLimit:    false,
@@ -330,6 +366,7 @@ func TestStringWrapSplit_WrappedStringSeq(t *testing.T) {
 			OrigLineNum:       1,
 			OrigByteOffset:    LineOffset{Start: 56, End: 65},
 			OrigRuneOffset:    LineOffset{Start: 56, End: 65},
+			WrappedByteOffset: LineOffset{Start: 64, End: 74},
 			SegmentInOrig:     7,
 			LastSegmentInOrig: false,
 			NotWithinLimit:    false,
@@ -342,6 +379,7 @@ func TestStringWrapSplit_WrappedStringSeq(t *testing.T) {
 			OrigLineNum:       1,
 			OrigByteOffset:    LineOffset{Start: 65, End: 74},
 			OrigRuneOffset:    LineOffset{Start: 65, End: 74},
+			WrappedByteOffset: LineOffset{Start: 75, End: 85},
 			SegmentInOrig:     8,
 			LastSegmentInOrig: false,
 			NotWithinLimit:    false,
@@ -354,6 +392,7 @@ func TestStringWrapSplit_WrappedStringSeq(t *testing.T) {
 			OrigLineNum:       1,
 			OrigByteOffset:    LineOffset{Start: 74, End: 83},
 			OrigRuneOffset:    LineOffset{Start: 74, End: 83},
+			WrappedByteOffset: LineOffset{Start: 86, End: 96},
 			SegmentInOrig:     9,
 			LastSegmentInOrig: false,
 			NotWithinLimit:    false,
@@ -366,6 +405,7 @@ func TestStringWrapSplit_WrappedStringSeq(t *testing.T) {
 			OrigLineNum:       1,
 			OrigByteOffset:    LineOffset{Start: 83, End: 87},
 			OrigRuneOffset:    LineOffset{Start: 83, End: 87},
+			WrappedByteOffset: LineOffset{Start: 97, End: 101},
 			SegmentInOrig:     10,
 			LastSegmentInOrig: true,
 			NotWithinLimit:    false,
@@ -382,3 +422,175 @@ func TestStringWrapSplit_WrappedStringSeq(t *testing.T) {
 		})
 	}
 }
+
+// TestStringWrap_OffsetsCoverInput tests that the byte and rune offsets of
+// the wrapped lines are contiguous and span the whole original string,
+// including tabs and multi-byte whitespace.
+func TestStringWrap_OffsetsCoverInput(t *testing.T) {
+	inputs := []string{
+		"hello\tworld\tand\tmore tabs here",
+		"foo\u2028bar baz\u3000qux\u3000\u3000quux",
+		"\t\tindented text\vwith a vertical tab",
+		"Supercalifragilisticexpialidocious 🌟🌟🌟",
+		"\r ",
+		"trailing spaces   ",
+		"ab\n \t\v",
+	}
+
+	for idx, input := range inputs {
+		t.Run(fmt.Sprintf("Offsets Test %d", idx+1), func(t *testing.T) {
+			_, seq, _ := StringWrapSplit(input, 6, 4, true)
+			byteIdx, runeIdx := 0, 0
+			for _, wrappedLine := range seq.WrappedLines {
+				assert.Equal(t, byteIdx, wrappedLine.OrigByteOffset.Start)
+				assert.Equal(t, runeIdx, wrappedLine.OrigRuneOffset.Start)
+				byteIdx = wrappedLine.OrigByteOffset.End
+				runeIdx = wrappedLine.OrigRuneOffset.End
+			}
+			assert.Equal(t, len(input), byteIdx)
+			assert.Equal(t, utf8.RuneCountInString(input), runeIdx)
+		})
+	}
+}
+
+// TestStringWrap_OrigOffsets tests the byte and rune offsets of each
+// wrapped line in the original string with a variety of test cases. The
+// offsets follow the input written to each line, where they were once
+// derived from the length of the wrapped line, which miscounted escape
+// sequences, wide whitespace and trimmed whitespace.
+func TestStringWrap_OrigOffsets(t *testing.T) {
+	tests := []struct {
+		input     string
+		splitWord bool
+		bytes     []LineOffset
+		runes     []LineOffset
+	}{
+		{
+			// previously {0, 1}.
+			input: "\x1b[31m",
+			bytes: []LineOffset{{Start: 0, End: 5}},
+			runes: []LineOffset{{Start: 0, End: 5}},
+		},
+		{
+			// previously {0, 3} and {3, 6}, in runes too.
+			input:     "\u3000-wor  ",
+			splitWord: true,
+			bytes:     []LineOffset{{Start: 0, End: 5}, {Start: 5, End: 9}},
+			runes:     []LineOffset{{Start: 0, End: 3}, {Start: 3, End: 7}},
+		},
+		{
+			// previously {0, 1}, leaving the trimmed space uncovered.
+			input: "\r ",
+			bytes: []LineOffset{{Start: 0, End: 2}},
+			runes: []LineOffset{{Start: 0, End: 2}},
+		},
+		{
+			// previously {3, 7}.
+			input: "ab  cd  ",
+			bytes: []LineOffset{{Start: 0, End: 3}, {Start: 3, End: 8}},
+			runes: []LineOffset{{Start: 0, End: 3}, {Start: 3, End: 8}},
+		},
+		{
+			// previously {3, 4}.
+			input: "ab\n \t\v",
+			bytes: []LineOffset{{Start: 0, End: 3}, {Start: 3, End: 6}},
+			runes: []LineOffset{{Start: 0, End: 3}, {Start: 3, End: 6}},
+		},
+		{
+			input:     "héllo wörld",
+			splitWord: true,
+			bytes: []LineOffset{
+				{Start: 0, End: 3}, {Start: 3, End: 6}, {Start: 6, End: 10}, {Start: 10, End: 13},
+			},
+			runes: []LineOffset{
+				{Start: 0, End: 2}, {Start: 2, End: 5}, {Start: 5, End: 8}, {Start: 8, End: 11},
+			},
+		},
+	}
+
+	for idx, tt := range tests {
+		t.Run(fmt.Sprintf("Orig Offsets Test %d", idx+1), func(t *testing.T) {
+			_, seq, err := stringWrap(tt.input, 3, 4, true, tt.splitWord)
+			assert.Nil(t, err)
+			var bytes, runes []LineOffset
+			for _, wrappedLine := range seq.WrappedLines {
+				bytes = append(bytes, wrappedLine.OrigByteOffset)
+				runes = append(runes, wrappedLine.OrigRuneOffset)
+			}
+			assert.Equal(t, tt.bytes, bytes)
+			assert.Equal(t, tt.runes, runes)
+		})
+	}
+}
+
+// TestStringWrap_WideSpaces tests that whitespace wider than one column,
+// such as the ideographic space, is measured by its full width when
+// deciding whether it fits, and that trimmed whitespace adds no width to
+// the line it was trimmed from. Each case notes the previous output.
+func TestStringWrap_WideSpaces(t *testing.T) {
+	tests := []stringWrapTestCase{
+		{
+			// previously "wo-\nrld\nw-\nor-\nld".
+			input:          "world\u3000world",
+			wrapped:        "wo-\nrld\nwo-\nrld",
+			limit:          3,
+			trimWhitespace: true,
+			splitWord:      true,
+		},
+		{
+			// previously "c\u00a0  \u3000\u3000", which is wider than the
+			// limit.
+			input:          "c\u00a0\t\u3000\u3000",
+			wrapped:        "c\u00a0  \u3000\n\u3000",
+			limit:          7,
+			trimWhitespace: false,
+			splitWord:      false,
+		},
+		{
+			// previously "  w-\nor".
+			input:          "\u3000  wor",
+			wrapped:        "wor",
+			limit:          5,
+			trimWhitespace: true,
+			splitWord:      true,
+		},
+		{
+			// previously " de".
+			input:          "\u3000 de\u3000",
+			wrapped:        "de",
+			limit:          7,
+			trimWhitespace: true,
+			splitWord:      true,
+		},
+		{
+			// previously "ab\n\nworwor".
+			input:          "ab\n\u3000worwor",
+			wrapped:        "ab\nworwor",
+			limit:          5,
+			trimWhitespace: true,
+			splitWord:      false,
+		},
+	}
+
+	for idx, tt := range tests {
+		t.Run(fmt.Sprintf("Wide Spaces Test %d", idx+1), func(t *testing.T) {
+			wrapped, _, err := wrapString(tt)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.wrapped, wrapped)
+		})
+	}
+}
+
+// TestStringWrap_TrimmedWidth tests that the width of trimmed lines does
+// not count ANSI escape sequences or the trimmed whitespace.
+func TestStringWrap_TrimmedWidth(t *testing.T) {
+	input := "\x1b[31mred\x1b[0m text   \x1b[1mbold\x1b[0m normal"
+	wrapped, seq, _ := StringWrap(input, 10, 4, true)
+	assert.Equal(t, "\x1b[31mred\x1b[0m text\n\x1b[1mbold\x1b[0m\nnormal", wrapped)
+
+	var widths []int
+	for _, wrappedLine := range seq.WrappedLines {
+		widths = append(widths, wrappedLine.Width)
+	}
+	assert.Equal(t, []int{8, 4, 6}, widths)
+}
@@ -0,0 +1,355 @@
+package stringwrap
+
+import (
+	"errors"
+	"strings"
+)
+
+// TableBorder determines how the borders of a table are drawn.
+type TableBorder int
+
+const (
+	// BorderASCII draws borders with '+', '-' and '|'.
+	BorderASCII TableBorder = iota
+	// BorderUnicode draws borders with box-drawing characters.
+	BorderUnicode
+	// BorderMarkdown renders a Markdown pipe table, whose first row is
+	// always the header. A Markdown row cannot span lines, so cells are
+	// never wrapped, and hard breaks within cells are written as "<br>".
+	BorderMarkdown
+)
+
+// VerticalAlignment determines where the lines of a cell are placed
+// within a row that is taller than the cell.
+type VerticalAlignment int
+
+const (
+	// AlignTop places the lines at the top of the row.
+	AlignTop VerticalAlignment = iota
+	// AlignMiddle places the lines in the middle of the row, with any
+	// odd line of padding below them.
+	AlignMiddle
+	// AlignBottom places the lines at the bottom of the row.
+	AlignBottom
+)
+
+// tableRules holds the characters that the borders of a table are drawn
+// with: the horizontal and vertical lines, and the left, inner and right
+// joints of the rules at the top, in the middle and at the bottom
+type tableRules struct {
+	horizontal string
+	vertical   string
+	top        [3]string
+	middle     [3]string
+	bottom     [3]string
+}
+
+// tableBorders holds the rules of each border that is drawn with lines
+var tableBorders = map[TableBorder]tableRules{
+	BorderASCII: {
+		horizontal: "-",
+		vertical:   "|",
+		top:        [3]string{"+", "+", "+"},
+		middle:     [3]string{"+", "+", "+"},
+		bottom:     [3]string{"+", "+", "+"},
+	},
+	BorderUnicode: {
+		horizontal: "─",
+		vertical:   "│",
+		top:        [3]string{"┌", "┬", "┐"},
+		middle:     [3]string{"├", "┼", "┤"},
+		bottom:     [3]string{"└", "┴", "┘"},
+	},
+}
+
+// Table holds the configuration used to render rows of cells as a table.
+// Cells are measured and wrapped exactly as Wrapper wraps strings, so
+// ANSI escape sequences have no width and are never split, and grapheme
+// clusters are measured by their viewable width.
+type Table struct {
+	// Width is the maximum viewable width of the rendered table,
+	// including its borders and padding. Zero means no maximum, so cells
+	// only wrap at hard breaks.
+	Width int
+	// Border determines how the borders of the table are drawn.
+	Border TableBorder
+	// Header draws a rule below the first row. Markdown tables always
+	// have a header.
+	Header bool
+	// RowRules draws a rule between every other pair of rows.
+	RowRules bool
+	// Padding is the number of spaces on either side of each cell.
+	Padding int
+	// Align holds the horizontal alignment of each column. Columns past
+	// its end are aligned to the left.
+	Align []Alignment
+	// VerticalAlign determines where the lines of a cell are placed
+	// within a row that is taller than the cell.
+	VerticalAlign VerticalAlignment
+	// TabSize defines how many spaces a tab character expands to.
+	TabSize int
+	// Measurer measures the viewable width of grapheme clusters and
+	// whitespace. If nil, widths are measured with go-runewidth.
+	Measurer Measurer
+}
+
+// align returns the horizontal alignment of the column
+func (t Table) align(column int) Alignment {
+	if column < len(t.Align) {
+		return t.Align[column]
+	}
+	return AlignLeft
+}
+
+// wrapper returns the Wrapper that cells are measured and wrapped with
+func (t Table) wrapper() Wrapper {
+	return Wrapper{
+		TabSize:        t.TabSize,
+		TrimWhitespace: true,
+		SplitWord:      true,
+		Measurer:       t.Measurer,
+	}
+}
+
+// columnWidths allocates the width of each column from the min-content
+// and max-content widths of its cells, like the automatic table layout
+// of CSS. Columns get their max-content width if every column fits,
+// otherwise their min-content width plus a share of the remaining width
+// in proportion to how much wider they could be. If even the min-content
+// widths do not fit, the available width is shared in proportion to them
+// and words are split.
+func columnWidths(minWidths []int, maxWidths []int, available int) ([]int, error) {
+	widths := make([]int, len(maxWidths))
+	sumMin, sumMax := 0, 0
+	for i := range maxWidths {
+		sumMin += minWidths[i]
+		sumMax += maxWidths[i]
+	}
+	if available < 0 || sumMax <= available {
+		copy(widths, maxWidths)
+		return widths, nil
+	}
+
+	// each column gets at least its floor, and the rest of the width is
+	// shared in proportion to the weights, rounding so that the shares
+	// add up to exactly the rest.
+	floors := make([]int, len(maxWidths))
+	weights := make([]int, len(maxWidths))
+	for i := range maxWidths {
+		if sumMin <= available {
+			floors[i] = minWidths[i]
+			weights[i] = maxWidths[i] - minWidths[i]
+		} else {
+			// a column cannot be narrower than two, as words are split
+			// with a hyphen after at least one grapheme.
+			floors[i] = min(maxWidths[i], 2)
+			weights[i] = max(minWidths[i]-floors[i], 0)
+		}
+		available -= floors[i]
+	}
+	if available < 0 {
+		return nil, errors.New("width is too narrow for the table")
+	}
+
+	total := 0
+	for _, weight := range weights {
+		total += weight
+	}
+	given, acc := 0, 0
+	for i := range widths {
+		acc += weights[i]
+		share := available*acc/max(total, 1) - given
+		widths[i] = floors[i] + share
+		given += share
+	}
+	return widths, nil
+}
+
+// Render lays out the rows of cells as a table. Rows may have different
+// numbers of cells, and missing cells are left empty. Each cell is
+// wrapped to the width allocated to its column, with the styles of each
+// line closed before the border after it.
+//
+// An error is returned if the padding is negative, or if the width is
+// too narrow to give every column room for at least two columns of text.
+func (t Table) Render(rows [][]string) (string, error) {
+	if t.Padding < 0 {
+		return "", errors.New("padding must not be negative")
+	}
+	columns := 0
+	for _, row := range rows {
+		columns = max(columns, len(row))
+	}
+	if columns == 0 {
+		return "", nil
+	}
+	if t.Border == BorderMarkdown {
+		return t.renderMarkdown(rows, columns)
+	}
+	rules, ok := tableBorders[t.Border]
+	if !ok {
+		return "", errors.New("unknown table border")
+	}
+
+	// allocate the width available to the text of the cells, after the
+	// borders and padding. Words are only split when the columns are
+	// narrower than their min-content widths, which are measured from
+	// whole words.
+	wrapper := t.wrapper()
+	words := wrapper
+	words.SplitWord = false
+	minWidths := make([]int, columns)
+	maxWidths := make([]int, columns)
+	for _, row := range rows {
+		for j, cell := range row {
+			minWidths[j] = max(minWidths[j], words.MinContentWidth(cell))
+			maxWidths[j] = max(maxWidths[j], words.MaxContentWidth(cell))
+		}
+	}
+	available := -1
+	if t.Width > 0 {
+		border := cellsWidth(rules.vertical, t.Measurer)
+		available = t.Width - (columns+1)*border - 2*columns*t.Padding
+	}
+	widths, err := columnWidths(minWidths, maxWidths, available)
+	if err != nil {
+		return "", err
+	}
+
+	var output strings.Builder
+	t.writeRule(&output, rules.horizontal, rules.top, widths)
+	for i, row := range rows {
+		if i > 0 && (t.RowRules || (i == 1 && t.Header)) {
+			t.writeRule(&output, rules.horizontal, rules.middle, widths)
+		}
+
+		// wrap each cell to the width of its column, then write the lines
+		// of the row, placing each cell within the height of the row.
+		cells := make([][]tabCell, columns)
+		height := 1
+		for j, width := range widths {
+			if j >= len(row) || row[j] == "" {
+				continue
+			}
+			wrapper.Limit = max(width, 2)
+			wrapped, seq, err := wrapper.Wrap(row[j])
+			if err != nil {
+				return "", err
+			}
+			cells[j] = styledCells(wrapped, seq)
+			height = max(height, len(cells[j]))
+		}
+		for line := 0; line < height; line++ {
+			output.WriteString(rules.vertical)
+			for j, width := range widths {
+				var cell tabCell
+				if idx := line - t.verticalOffset(len(cells[j]), height); idx >= 0 && idx < len(cells[j]) {
+					cell = cells[j][idx]
+				}
+				output.WriteString(strings.Repeat(" ", t.Padding))
+				output.WriteString(padLine(cell.text, t.Measurer, width-cell.width, t.align(j)))
+				output.WriteString(strings.Repeat(" ", t.Padding))
+				output.WriteString(rules.vertical)
+			}
+			output.WriteByte('\n')
+		}
+	}
+	t.writeRule(&output, rules.horizontal, rules.bottom, widths)
+	return strings.TrimSuffix(output.String(), "\n"), nil
+}
+
+// verticalOffset returns the number of lines above a cell of the given
+// number of lines within a row of the given height
+func (t Table) verticalOffset(lines int, height int) int {
+	switch t.VerticalAlign {
+	case AlignMiddle:
+		return (height - lines) / 2
+	case AlignBottom:
+		return height - lines
+	default:
+		return 0
+	}
+}
+
+// writeRule writes a horizontal rule across the columns, with the given
+// joints at its left, between the columns and at its right
+func (t Table) writeRule(output *strings.Builder, horizontal string, joints [3]string, widths []int) {
+	output.WriteString(joints[0])
+	for j, width := range widths {
+		if j > 0 {
+			output.WriteString(joints[1])
+		}
+		output.WriteString(strings.Repeat(horizontal, width+2*t.Padding))
+	}
+	output.WriteString(joints[2])
+	output.WriteByte('\n')
+}
+
+// renderMarkdown lays out the rows of cells as a Markdown pipe table,
+// escaping the pipes within cells and writing their hard breaks as
+// "<br>". The columns are padded to their widest cell, and the rule
+// below the header marks the alignment of each column.
+func (t Table) renderMarkdown(rows [][]string, columns int) (string, error) {
+	wrapper := t.wrapper()
+	wrapper.Limit = unboundedLimit
+	wrapper.LineBreak = "<br>"
+
+	cells := make([][]tabCell, len(rows))
+	widths := make([]int, columns)
+	for i := range widths {
+		// a rule needs at least three characters to mark its alignment.
+		widths[i] = 3
+	}
+	for i, row := range rows {
+		cells[i] = make([]tabCell, columns)
+		for j, text := range row {
+			wrapped, _, err := wrapper.Wrap(strings.ReplaceAll(text, "|", "\\|"))
+			if err != nil {
+				return "", err
+			}
+			cells[i][j] = tabCell{text: wrapped, width: cellsWidth(wrapped, t.Measurer)}
+			widths[j] = max(widths[j], cells[i][j].width)
+		}
+	}
+
+	padding := strings.Repeat(" ", t.Padding)
+	var output strings.Builder
+	for i, row := range cells {
+		if i == 1 {
+			t.writeMarkdownRule(&output, widths)
+		}
+		output.WriteByte('|')
+		for j, cell := range row {
+			output.WriteString(padding)
+			output.WriteString(padLine(cell.text, t.Measurer, widths[j]-cell.width, t.align(j)))
+			output.WriteString(padding)
+			output.WriteByte('|')
+		}
+		output.WriteByte('\n')
+	}
+	if len(cells) == 1 {
+		t.writeMarkdownRule(&output, widths)
+	}
+	return strings.TrimSuffix(output.String(), "\n"), nil
+}
+
+// writeMarkdownRule writes the rule below the header of a Markdown table,
+// marking the alignment of each column with colons
+func (t Table) writeMarkdownRule(output *strings.Builder, widths []int) {
+	padding := strings.Repeat(" ", t.Padding)
+	output.WriteByte('|')
+	for j, width := range widths {
+		output.WriteString(padding)
+		switch t.align(j) {
+		case AlignCenter:
+			output.WriteString(":" + strings.Repeat("-", width-2) + ":")
+		case AlignRight:
+			output.WriteString(strings.Repeat("-", width-1) + ":")
+		default:
+			output.WriteString(strings.Repeat("-", width))
+		}
+		output.WriteString(padding)
+		output.WriteByte('|')
+	}
+	output.WriteByte('\n')
+}
@@ -0,0 +1,151 @@
+package stringwrap
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// tableTestCase is a struct that contains the table configuration, the
+// rows rendered with it, and the expected output.
+type tableTestCase struct {
+	table    Table
+	rows     [][]string
+	expected string
+}
+
+// TestTable_Render tests that rows are laid out as a table, with cells
+// wrapped to the widths of their columns, with a variety of test cases.
+func TestTable_Render(t *testing.T) {
+	rows := [][]string{{"Name", "Size"}, {"stringwrap", "12 KB"}, {"日本語", "1"}}
+	tests := []tableTestCase{
+		{
+			table: Table{Padding: 1, Header: true},
+			rows:  rows,
+			expected: "+------------+-------+\n" +
+				"| Name       | Size  |\n" +
+				"+------------+-------+\n" +
+				"| stringwrap | 12 KB |\n" +
+				"| 日本語     | 1     |\n" +
+				"+------------+-------+",
+		},
+		{
+			table: Table{
+				Padding: 1, Border: BorderUnicode, RowRules: true,
+				Align: []Alignment{AlignLeft, AlignRight},
+			},
+			rows: rows,
+			expected: "┌────────────┬───────┐\n" +
+				"│ Name       │  Size │\n" +
+				"├────────────┼───────┤\n" +
+				"│ stringwrap │ 12 KB │\n" +
+				"├────────────┼───────┤\n" +
+				"│ 日本語     │     1 │\n" +
+				"└────────────┴───────┘",
+		},
+		{
+			table: Table{Width: 16, Padding: 1, VerticalAlign: AlignMiddle},
+			rows:  rows,
+			expected: "+--------+-----+\n" +
+				"| Name   | Si- |\n" +
+				"|        | ze  |\n" +
+				"| strin- | 12  |\n" +
+				"| gwrap  | KB  |\n" +
+				"| 日本語 | 1   |\n" +
+				"+--------+-----+",
+		},
+		{
+			table: Table{Width: 15, Padding: 1, VerticalAlign: AlignBottom},
+			rows:  rows,
+			expected: "+-------+-----+\n" +
+				"|       | Si- |\n" +
+				"| Name  | ze  |\n" +
+				"| stri- |     |\n" +
+				"| ngwr- | 12  |\n" +
+				"| ap    | KB  |\n" +
+				"| 日本- |     |\n" +
+				"| 語    | 1   |\n" +
+				"+-------+-----+",
+		},
+		{
+			table: Table{Padding: 1},
+			rows:  [][]string{{"\x1b[31mred text\x1b[0m", "a|b"}, {"x"}},
+			expected: "+----------+-----+\n" +
+				"| \x1b[31mred text\x1b[0m | a|b |\n" +
+				"| x        |     |\n" +
+				"+----------+-----+",
+		},
+		{
+			table: Table{Width: 12, Padding: 1},
+			rows:  [][]string{{"\x1b[31mred text\x1b[0m", "x"}},
+			expected: "+------+---+\n" +
+				"| \x1b[31mred\x1b[0m  | x |\n" +
+				"| \x1b[31mtext\x1b[0m |   |\n" +
+				"+------+---+",
+		},
+		{
+			table: Table{Border: BorderMarkdown, Padding: 1, Align: []Alignment{AlignCenter, AlignRight}},
+			rows:  rows,
+			expected: "|    Name    |  Size |\n" +
+				"| :--------: | ----: |\n" +
+				"| stringwrap | 12 KB |\n" +
+				"|   日本語   |     1 |",
+		},
+		{
+			table:    Table{Border: BorderMarkdown},
+			rows:     [][]string{{"a|b", "line\nbreak"}},
+			expected: "|a\\|b|line<br>break|\n|----|-------------|",
+		},
+		{
+			table:    Table{Padding: 1},
+			rows:     nil,
+			expected: "",
+		},
+	}
+
+	for idx, test := range tests {
+		t.Run(fmt.Sprintf("Table Test %d", idx+1), func(t *testing.T) {
+			output, err := test.table.Render(test.rows)
+			assert.Nil(t, err)
+			assert.Equal(t, test.expected, output)
+		})
+	}
+
+	_, err := Table{Width: 10, Padding: 1}.Render(rows)
+	assert.NotNil(t, err)
+
+	_, err = Table{Border: TableBorder(-1)}.Render(rows)
+	assert.NotNil(t, err)
+
+	_, err = Table{Width: 40, Padding: -1}.Render(rows)
+	assert.NotNil(t, err)
+
+	_, err = Table{Border: BorderMarkdown, Padding: -1}.Render(rows)
+	assert.NotNil(t, err)
+}
+
+// TestColumnWidths tests that the available width is allocated from the
+// min-content and max-content widths with a variety of test cases.
+func TestColumnWidths(t *testing.T) {
+	tests := []struct {
+		available int
+		expected  []int
+	}{
+		{available: -1, expected: []int{20, 10}},
+		{available: 100, expected: []int{20, 10}},
+		{available: 24, expected: []int{14, 10}},
+		{available: 10, expected: []int{3, 7}},
+	}
+
+	for idx, tt := range tests {
+		t.Run(fmt.Sprintf("Column Widths Test %d", idx+1), func(t *testing.T) {
+			widths, err := columnWidths([]int{4, 10}, []int{20, 10}, tt.available)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.expected, widths)
+		})
+	}
+
+	_, err := columnWidths([]int{4, 10}, []int{20, 10}, 3)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,311 @@
+package stringwrap
+
+import (
+	"bytes"
+	"io"
+	"strings"
+)
+
+// TabFlags control the formatting of a TabWriter, following the flags of
+// text/tabwriter.
+type TabFlags uint
+
+const (
+	// TabAlignRight aligns the text of each cell to the right of its
+	// column. It is ignored when padding with tabs.
+	TabAlignRight TabFlags = 1 << iota
+	// TabDebug writes a vertical bar between the cells of each line.
+	TabDebug
+	// TabIndent pads the empty cells at the start of a line with tabs,
+	// whatever the padding character.
+	TabIndent
+)
+
+// tabCell is a single cell of a line written to a TabWriter, along with
+// its viewable width
+type tabCell struct {
+	text  string
+	width int
+}
+
+// styledCells splits wrapped text into its lines, along with their
+// widths. Each line closes the styles it leaves open, so they do not run
+// into the padding after it, and the next line opens them again.
+func styledCells(wrapped string, seq *WrappedStringSeq) []tabCell {
+	cells := make([]tabCell, len(seq.WrappedLines))
+	style := ""
+	for i, wrappedLine := range seq.WrappedLines {
+		offset := wrappedLine.WrappedByteOffset
+		line := style + wrapped[offset.Start:offset.End]
+		style = activeStyle(line)
+		cells[i] = tabCell{text: closeStyle(line), width: wrappedLine.Width}
+	}
+	return cells
+}
+
+// tabLine is a line of cells written to a TabWriter, and whether it was
+// ended by a newline
+type tabLine struct {
+	cells   []tabCell
+	newline bool
+}
+
+// TabWriter aligns tab-separated cells into columns like text/tabwriter,
+// measuring each cell exactly as wrapping does: ANSI escape sequences
+// have no width, and grapheme clusters such as emoji and East Asian
+// characters are measured by their viewable width rather than in runes.
+//
+// Each cell is terminated by a tab, and the text after the last tab of a
+// line is not part of a column. A column block is a run of consecutive
+// lines with a cell in that column, and its width is that of its widest
+// cell plus the padding. Lines are buffered until Flush is called, or
+// until a line without tabs ends every column block.
+type TabWriter struct {
+	// MaxCellWidth is the maximum viewable width of the text of a cell.
+	// Cells that are wider are wrapped onto the following lines, with the
+	// other cells of those lines left empty. The text after the last tab
+	// of a line is not a cell, so it is never wrapped. Zero means cells
+	// are never wrapped.
+	MaxCellWidth int
+	// Measurer measures the viewable width of grapheme clusters and
+	// whitespace. If nil, widths are measured with go-runewidth.
+	Measurer Measurer
+
+	output   io.Writer
+	minWidth int
+	tabWidth int
+	padding  int
+	padChar  byte
+	flags    TabFlags
+
+	pending []byte
+	lines   []tabLine
+	widths  []int
+	buffer  bytes.Buffer
+}
+
+// NewTabWriter creates a TabWriter that writes to output, taking the same
+// parameters as text/tabwriter: the minimum width of a column including
+// its padding, the width of a tab used when padding with tabs, the
+// padding added to the widest cell of each column, the character cells
+// are padded with, and the formatting flags.
+func NewTabWriter(
+	output io.Writer, minWidth int, tabWidth int, padding int, padChar byte, flags TabFlags,
+) *TabWriter {
+	// padding with tabs enforces left alignment, as the position of a
+	// tab depends on the text before it.
+	if padChar == '\t' {
+		flags &^= TabAlignRight
+	}
+	return &TabWriter{
+		output:   output,
+		minWidth: minWidth,
+		tabWidth: tabWidth,
+		padding:  padding,
+		padChar:  padChar,
+		flags:    flags,
+	}
+}
+
+// Write buffers the text, splitting each complete line into cells. The
+// lines up to the last one without tabs are formatted and written to the
+// output, as no column block continues past it.
+func (t *TabWriter) Write(p []byte) (int, error) {
+	t.pending = append(t.pending, p...)
+	if err := t.parse(false); err != nil {
+		return 0, err
+	}
+	for i := len(t.lines) - 1; i >= 0; i-- {
+		if len(t.lines[i].cells) == 1 {
+			if err := t.flushLines(i + 1); err != nil {
+				return 0, err
+			}
+			break
+		}
+	}
+	return len(p), nil
+}
+
+// Flush formats and writes every buffered line. A last line that was not
+// ended by a newline is written without one.
+func (t *TabWriter) Flush() error {
+	if err := t.parse(true); err != nil {
+		return err
+	}
+	return t.flushLines(len(t.lines))
+}
+
+// parse splits the complete lines of the pending text into cells, along
+// with the incomplete last line if final is set. Tabs and newlines
+// within ANSI escape sequences do not end a cell.
+func (t *TabWriter) parse(final bool) error {
+	var texts []string
+	var err error
+	start, consumed := 0, 0
+	pending := string(t.pending)
+	tokenizeFunc(pending, t.Measurer, func(token Token) {
+		switch {
+		case err != nil:
+		case token.Kind == TokenTab:
+			texts = append(texts, pending[start:token.ByteOffset.Start])
+			start = token.ByteOffset.End
+		case token.Kind == TokenHardBreak && strings.HasSuffix(token.Text, "\n"):
+			// a carriage return before the newline stays in the last
+			// cell, which is never padded.
+			texts = append(texts, pending[start:token.ByteOffset.End-1])
+			err = t.appendLine(texts, true)
+			texts = nil
+			start, consumed = token.ByteOffset.End, token.ByteOffset.End
+		}
+	})
+	if err == nil && final && consumed < len(pending) {
+		// an empty cell after the last tab is dropped, as text/tabwriter
+		// does, so the cell before it is not padded.
+		if start < len(pending) {
+			texts = append(texts, pending[start:])
+		}
+		err = t.appendLine(texts, false)
+		consumed = len(pending)
+	}
+	t.pending = append(t.pending[:0], t.pending[consumed:]...)
+	return err
+}
+
+// appendLine measures the cells of a line, wrapping cells wider than the
+// maximum cell width onto the lines below, except for the text after the
+// last tab
+func (t *TabWriter) appendLine(texts []string, newline bool) error {
+	if t.MaxCellWidth == 0 {
+		cells := make([]tabCell, len(texts))
+		for i, text := range texts {
+			cells[i] = tabCell{text: text, width: cellsWidth(text, t.Measurer)}
+		}
+		t.lines = append(t.lines, tabLine{cells: cells, newline: newline})
+		return nil
+	}
+
+	wrapper := Wrapper{
+		Limit:          t.MaxCellWidth,
+		TabSize:        t.tabWidth,
+		TrimWhitespace: true,
+		SplitWord:      true,
+		Measurer:       t.Measurer,
+	}
+	rows := [][]tabCell{make([]tabCell, len(texts))}
+	for i, text := range texts {
+		// the text after the last tab is not part of a column, so it is
+		// never wrapped.
+		if i == len(texts)-1 {
+			rows[0][i] = tabCell{text: text, width: cellsWidth(text, t.Measurer)}
+			continue
+		}
+		wrapped, seq, err := wrapper.Wrap(text)
+		if err != nil {
+			return err
+		}
+		for j, cell := range styledCells(wrapped, seq) {
+			if j == len(rows) {
+				rows = append(rows, make([]tabCell, len(texts)))
+			}
+			rows[j][i] = cell
+		}
+	}
+	for j, cells := range rows {
+		t.lines = append(t.lines, tabLine{cells: cells, newline: newline || j < len(rows)-1})
+	}
+	return nil
+}
+
+// flushLines formats the first n buffered lines and writes them to the
+// output
+func (t *TabWriter) flushLines(n int) error {
+	t.buffer.Reset()
+	t.format(t.lines[:n], 0, n)
+	t.lines = append(t.lines[:0], t.lines[n:]...)
+	_, err := t.output.Write(t.buffer.Bytes())
+	return err
+}
+
+// format writes the lines [line0, line1), finding the column blocks of
+// the next column and formatting the columns to the right of each block
+// recursively, exactly as text/tabwriter does
+func (t *TabWriter) format(lines []tabLine, line0 int, line1 int) {
+	column := len(t.widths)
+	for this := line0; this < line1; this++ {
+		if column >= len(lines[this].cells)-1 {
+			continue
+		}
+
+		// the lines before the block have no cell in this column.
+		t.writeLines(lines, line0, this)
+		line0 = this
+
+		width := t.minWidth
+		for ; this < line1; this++ {
+			cells := lines[this].cells
+			if column >= len(cells)-1 {
+				break
+			}
+			width = max(width, cells[column].width+t.padding)
+		}
+
+		t.widths = append(t.widths, width)
+		t.format(lines, line0, this)
+		t.widths = t.widths[:len(t.widths)-1]
+		line0 = this
+	}
+	t.writeLines(lines, line0, line1)
+}
+
+// writeLines writes the lines [line0, line1), padding each cell to the
+// width of its column
+func (t *TabWriter) writeLines(lines []tabLine, line0 int, line1 int) {
+	for _, line := range lines[line0:line1] {
+		useTabs := t.flags&TabIndent != 0
+		for j, cell := range line.cells {
+			if j > 0 && t.flags&TabDebug != 0 {
+				t.buffer.WriteByte('|')
+			}
+			switch {
+			case cell.text == "":
+				if j < len(t.widths) {
+					t.writePadding(cell.width, t.widths[j], useTabs)
+				}
+			case t.flags&TabAlignRight == 0:
+				useTabs = false
+				t.buffer.WriteString(cell.text)
+				if j < len(t.widths) {
+					t.writePadding(cell.width, t.widths[j], false)
+				}
+			default:
+				useTabs = false
+				if j < len(t.widths) {
+					t.writePadding(cell.width, t.widths[j], false)
+				}
+				t.buffer.WriteString(cell.text)
+			}
+		}
+		if line.newline {
+			t.buffer.WriteByte('\n')
+		}
+	}
+}
+
+// writePadding pads a cell of the given width to the width of its column,
+// with tabs if the padding character is a tab or useTabs is set
+func (t *TabWriter) writePadding(textWidth int, cellWidth int, useTabs bool) {
+	if t.padChar == '\t' || useTabs {
+		// tabs have no width to pad with.
+		if t.tabWidth == 0 {
+			return
+		}
+		cellWidth = (cellWidth + t.tabWidth - 1) / t.tabWidth * t.tabWidth
+		for i := 0; i < (cellWidth-textWidth+t.tabWidth-1)/t.tabWidth; i++ {
+			t.buffer.WriteByte('\t')
+		}
+		return
+	}
+	for i := 0; i < cellWidth-textWidth; i++ {
+		t.buffer.WriteByte(t.padChar)
+	}
+}
@@ -0,0 +1,182 @@
+package stringwrap
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"text/tabwriter"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// tabWriterParams is a struct that contains the parameters of a
+// TabWriter and of the equivalent text/tabwriter writer.
+type tabWriterParams struct {
+	minWidth int
+	tabWidth int
+	padding  int
+	padChar  byte
+	flags    TabFlags
+	stdFlags uint
+}
+
+// TestTabWriter_Compatible tests that plain text is formatted exactly as
+// text/tabwriter formats it with a variety of test cases.
+func TestTabWriter_Compatible(t *testing.T) {
+	inputs := []string{
+		"a\tb\tc\naa\tbb\tcc\naaa\tbbb\n",
+		"name\tsize\nlonger name\t12\n\nafter\tblank\tline\nx\n",
+		"\t\tindented\n\tone\nnone\n",
+		"a\tb\t\nccc\tddd\t\npartial\tlast",
+		"no tabs at all\nstill none",
+		"",
+		"bb\tx\ty\t",
+		"ax\tybba accca \t",
+	}
+	params := []tabWriterParams{
+		{minWidth: 0, tabWidth: 8, padding: 1, padChar: ' '},
+		{minWidth: 5, tabWidth: 4, padding: 2, padChar: '.'},
+		{minWidth: 0, tabWidth: 8, padding: 1, padChar: ' ', flags: TabAlignRight, stdFlags: tabwriter.AlignRight},
+		{minWidth: 0, tabWidth: 8, padding: 1, padChar: ' ', flags: TabDebug, stdFlags: tabwriter.Debug},
+		{minWidth: 0, tabWidth: 8, padding: 1, padChar: '\t'},
+		{minWidth: 4, tabWidth: 4, padding: 0, padChar: '\t', flags: TabAlignRight, stdFlags: tabwriter.AlignRight},
+		{minWidth: 0, tabWidth: 4, padding: 1, padChar: ' ', flags: TabIndent, stdFlags: tabwriter.TabIndent},
+		{minWidth: 5, tabWidth: 4, padding: 2, padChar: ' '},
+		{
+			minWidth: 4, tabWidth: 2, padding: 1, padChar: '.',
+			flags: TabAlignRight | TabIndent, stdFlags: tabwriter.AlignRight | tabwriter.TabIndent,
+		},
+		{
+			minWidth: 2, tabWidth: 8, padding: 1, padChar: '-',
+			flags: TabAlignRight | TabDebug, stdFlags: tabwriter.AlignRight | tabwriter.Debug,
+		},
+	}
+
+	for idx, input := range inputs {
+		for paramIdx, param := range params {
+			t.Run(fmt.Sprintf("Tab Writer Test %d/%d", idx+1, paramIdx+1), func(t *testing.T) {
+				var expected bytes.Buffer
+				std := tabwriter.NewWriter(
+					&expected, param.minWidth, param.tabWidth, param.padding, param.padChar, param.stdFlags,
+				)
+				_, _ = std.Write([]byte(input))
+				_ = std.Flush()
+
+				var output bytes.Buffer
+				writer := NewTabWriter(
+					&output, param.minWidth, param.tabWidth, param.padding, param.padChar, param.flags,
+				)
+				n, err := writer.Write([]byte(input))
+				assert.Nil(t, err)
+				assert.Equal(t, len(input), n)
+				assert.Nil(t, writer.Flush())
+				assert.Equal(t, expected.String(), output.String())
+
+				// writing a byte at a time produces the same output.
+				output.Reset()
+				writer = NewTabWriter(
+					&output, param.minWidth, param.tabWidth, param.padding, param.padChar, param.flags,
+				)
+				for i := 0; i < len(input); i++ {
+					_, err = writer.Write([]byte{input[i]})
+					assert.Nil(t, err)
+				}
+				assert.Nil(t, writer.Flush())
+				assert.Equal(t, expected.String(), output.String())
+			})
+		}
+	}
+}
+
+// tabWriterTestCase is a struct that contains the input string, the
+// maximum cell width and flags, and the expected output.
+type tabWriterTestCase struct {
+	input        string
+	maxCellWidth int
+	flags        TabFlags
+	expected     string
+}
+
+// TestTabWriter tests that cells are measured by their viewable width, and
+// wrapped to the maximum cell width, with a variety of test cases.
+func TestTabWriter(t *testing.T) {
+	tests := []tabWriterTestCase{
+		{
+			input:    "\x1b[31mred\x1b[0m\tx\nplain\ty\n",
+			expected: "\x1b[31mred\x1b[0m   x\nplain y\n",
+		},
+		{
+			input:    "日本語\tx\nab\ty\n",
+			expected: "日本語 x\nab     y\n",
+		},
+		{
+			input:    "👩\u200D💻 dev\tx\ncafe\u0301s\ty\n",
+			expected: "👩\u200D💻 dev x\ncafe\u0301s  y\n",
+		},
+		{
+			input:    "\x1b[1mbold\x1b[0m\t日本\tz\nb\tc\td\n",
+			flags:    TabAlignRight | TabDebug,
+			expected: " \x1b[1mbold\x1b[0m| 日本|z\n    b|    c|d\n",
+		},
+		{
+			input:        "id\tThe quick brown fox\tend\n2\tshort\tx\n",
+			maxCellWidth: 10,
+			expected:     "id The quick end\n   brown fox \n2  short     x\n",
+		},
+		{
+			input:        "\x1b[32mgreen text here\x1b[0m\tx\ny\tz\n",
+			maxCellWidth: 10,
+			expected: "\x1b[32mgreen text\x1b[0m x\n" +
+				"\x1b[32mhere\x1b[0m       \n" +
+				"y          z\n",
+		},
+		{
+			input:        "Supercalifragilistic\tx",
+			maxCellWidth: 8,
+			flags:        TabDebug,
+			expected:     "Superca- |x\nlifragi- |\nlistic   |",
+		},
+		{
+			input:        "ab\tthe text after the last tab\ncdefgh\tx\n",
+			maxCellWidth: 4,
+			expected:     "ab   the text after the last tab\ncde- x\nfgh  \n",
+		},
+		{
+			input:    "a\tb\r\nccc\td\r\n",
+			expected: "a   b\r\nccc d\r\n",
+		},
+	}
+
+	for idx, test := range tests {
+		t.Run(fmt.Sprintf("Tab Writer Test %d", idx+1), func(t *testing.T) {
+			var output bytes.Buffer
+			writer := NewTabWriter(&output, 0, 8, 1, ' ', test.flags)
+			writer.MaxCellWidth = test.maxCellWidth
+			_, err := writer.Write([]byte(test.input))
+			assert.Nil(t, err)
+			assert.Nil(t, writer.Flush())
+			assert.Equal(t, test.expected, output.String())
+		})
+	}
+}
+
+// TestTabWriter_Streaming tests that lines are written once a line
+// without tabs ends every column block, and the rest when flushing.
+func TestTabWriter_Streaming(t *testing.T) {
+	var output bytes.Buffer
+	writer := NewTabWriter(&output, 0, 8, 1, ' ', 0)
+
+	_, _ = writer.Write([]byte("a\tb\nccc\t"))
+	assert.Equal(t, "", output.String())
+
+	_, _ = writer.Write([]byte("d\nheader\nx\ty"))
+	assert.Equal(t, "a   b\nccc d\nheader\n", output.String())
+
+	assert.Nil(t, writer.Flush())
+	assert.Equal(t, "a   b\nccc d\nheader\nx y", output.String())
+
+	writer = NewTabWriter(&output, 0, 8, 1, ' ', 0)
+	writer.MaxCellWidth = 1
+	_, err := writer.Write([]byte("a\tb\n"))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,244 @@
+package stringwrap
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/galactixx/ansiwalker"
+	"github.com/rivo/uniseg"
+)
+
+// TokenKind identifies the type of a Token produced by Tokenize.
+type TokenKind int
+
+const (
+	// TokenWord is a run of grapheme clusters (and non-breaking
+	// spaces) that is never broken apart unless word splitting is
+	// enabled.
+	TokenWord TokenKind = iota
+	// TokenSpace is a single breakable whitespace character.
+	TokenSpace
+	// TokenTab is a tab character, whose width depends on the column
+	// it is written at and is therefore resolved during wrapping.
+	TokenTab
+	// TokenHardBreak is a newline or other line separator, where a
+	// carriage return followed by a line feed is a single token.
+	TokenHardBreak
+	// TokenControl is a vertical tab or form feed, which ends the
+	// current word but is not written to the output.
+	TokenControl
+	// TokenANSI is an ANSI escape sequence, which is written to the
+	// output but has no visual width.
+	TokenANSI
+)
+
+// Token is a single lexical unit of a string, along with its visual
+// width and its location in the original string.
+type Token struct {
+	// The kind of the token.
+	Kind TokenKind
+	// The original text of the token.
+	Text string
+	// The viewable width of the token (zero for tabs, hard breaks,
+	// controls and ANSI sequences).
+	Width int
+	// The byte start and end offsets of this token in the original
+	// string.
+	ByteOffset LineOffset
+	// The rune start and end offsets of this token in the original
+	// string.
+	RuneOffset LineOffset
+	// Whether a word token contains a non-breaking space, which
+	// prevents it from being split.
+	HasNbsp bool
+}
+
+// TokenizedString holds the tokens of a string, so that the grapheme
+// segmentation and width measurement only need to be done once no
+// matter how many times the string is wrapped.
+type TokenizedString struct {
+	// Tokens is the list of tokens in the order they appear in the
+	// original string.
+	Tokens []Token
+}
+
+// text joins the tokens back into the original string
+func (t *TokenizedString) text() string {
+	var str strings.Builder
+	for _, token := range t.Tokens {
+		str.WriteString(token.Text)
+	}
+	return str.String()
+}
+
+// isHardBreak returns true if the rune is a newline or other line
+// separator that ends the original line
+func isHardBreak(r rune) bool {
+	switch r {
+	case '\n', '\r', '\u0085', '\u2028', '\u2029':
+		return true
+	}
+	return false
+}
+
+// tokenizer manages state for splitting a string into tokens
+type tokenizer struct {
+	str     string
+	yield   func(Token)
+	word    Token
+	inWord  bool
+	runeIdx int
+}
+
+// flushWord emits the pending word token, if there is one
+func (t *tokenizer) flushWord() {
+	if t.inWord {
+		t.yield(t.word)
+		t.inWord = false
+	}
+}
+
+// appendWord extends the pending word token with the text between
+// the start and end byte offsets, starting a new word if necessary.
+func (t *tokenizer) appendWord(start int, end int, width int, nbsp bool) {
+	runeCount := utf8.RuneCountInString(t.str[start:end])
+	if !t.inWord {
+		t.word = Token{
+			Kind:       TokenWord,
+			ByteOffset: LineOffset{Start: start, End: start},
+			RuneOffset: LineOffset{Start: t.runeIdx, End: t.runeIdx},
+		}
+		t.inWord = true
+	}
+	t.word.ByteOffset.End = end
+	t.word.RuneOffset.End += runeCount
+	t.word.Text = t.str[t.word.ByteOffset.Start:end]
+	t.word.Width += width
+	t.word.HasNbsp = t.word.HasNbsp || nbsp
+	t.runeIdx += runeCount
+}
+
+// emit ends any pending word and appends a non-word token covering
+// the text between the start and end byte offsets.
+func (t *tokenizer) emit(kind TokenKind, start int, end int, width int) {
+	t.flushWord()
+	runeCount := utf8.RuneCountInString(t.str[start:end])
+	t.yield(Token{
+		Kind:       kind,
+		Text:       t.str[start:end],
+		Width:      width,
+		ByteOffset: LineOffset{Start: start, End: end},
+		RuneOffset: LineOffset{Start: t.runeIdx, End: t.runeIdx + runeCount},
+	})
+	t.runeIdx += runeCount
+}
+
+// tokenize splits the string into words, whitespace, hard breaks and
+// ANSI escape sequences, measuring widths with the measurer.
+func tokenize(str string, m Measurer) []Token {
+	var tokens []Token
+	tokenizeFunc(str, m, func(token Token) { tokens = append(tokens, token) })
+	return tokens
+}
+
+// tokenizeFunc splits the string into tokens like tokenize, passing each
+// token to yield as soon as it is complete rather than collecting them.
+func tokenizeFunc(str string, m Measurer, yield func(Token)) {
+	t := tokenizer{str: str, yield: yield}
+	state := -1
+	idx := 0
+
+	// iterate through each rune in the string
+	for idx < len(str) {
+		r, rSize, next, _ := ansiwalker.ANSIWalk(str, idx)
+
+		// an escape sequence that runs to the end of the string has no
+		// visible rune after it.
+		if next < 0 {
+			t.emit(TokenANSI, idx, len(str), 0)
+			break
+		}
+
+		// the rune after an escape sequence may start another one, so the
+		// walk resumes from it rather than treating it as visible.
+		rIdx := next - rSize
+		if rIdx > idx {
+			t.emit(TokenANSI, idx, rIdx, 0)
+			state = -1
+			idx = rIdx
+			continue
+		}
+
+		// handle the different types of runes in the string
+		switch {
+		case r == '\u00A0':
+			t.appendWord(idx, idx+rSize, measureWidth(m, str[idx:idx+rSize]), true)
+			idx += rSize
+		case unicode.IsSpace(r):
+			// Handle the different types of whitespace characters
+			// in the string (e.g., space, newline, tab, etc.).
+			switch {
+			case isHardBreak(r):
+				// a carriage return followed by a line feed is a single
+				// hard break.
+				if r == '\r' && idx+1 < len(str) && str[idx+1] == '\n' {
+					rSize += 1
+				}
+				t.emit(TokenHardBreak, idx, idx+rSize, 0)
+			case r == '\t':
+				t.emit(TokenTab, idx, idx+rSize, 0)
+			case r == '\v' || r == '\f':
+				t.emit(TokenControl, idx, idx+rSize, 0)
+			default:
+				t.emit(TokenSpace, idx, idx+rSize, measureWidth(m, str[idx:idx+rSize]))
+			}
+			state = -1
+			idx += rSize
+		default:
+			// Step through the string one grapheme at a time.
+			cluster, _, _, st := uniseg.StepString(str[idx:], state)
+			state = st
+
+			// If the cluster is not empty, add the cluster to the
+			// current word along with its width.
+			if cluster != "" {
+				clusterWidth := measureWidth(m, cluster)
+				t.appendWord(idx, idx+len(cluster), clusterWidth, false)
+				idx += len(cluster)
+			} else {
+				idx += rSize
+			}
+		}
+	}
+	t.flushWord()
+}
+
+// Tokenize splits the input string into the words, whitespace, tabs,
+// hard breaks and ANSI escape sequences that the wrapping algorithm
+// operates on, measuring the width of each grapheme cluster once.
+//
+// The result can be wrapped any number of times, at any limit, with
+// Wrap and WrapSplit, producing exactly the same output and metadata as
+// StringWrap and StringWrapSplit would for the original string. This is
+// useful when the same text must be re-wrapped often, such as on every
+// terminal resize.
+func Tokenize(str string) *TokenizedString {
+	return &TokenizedString{Tokens: tokenize(str, nil)}
+}
+
+// Wrap wraps the tokenized string to the specified viewable-width limit,
+// with the same semantics as StringWrap.
+func (t *TokenizedString) Wrap(limit int, tabSize int, trimWhitespace bool) (
+	string, *WrappedStringSeq, error,
+) {
+	return wrapTokens(t.Tokens, limit, tabSize, trimWhitespace, false)
+}
+
+// WrapSplit wraps the tokenized string to the specified viewable-width
+// limit, with the same semantics as StringWrapSplit.
+func (t *TokenizedString) WrapSplit(limit int, tabSize int, trimWhitespace bool) (
+	string, *WrappedStringSeq, error,
+) {
+	return wrapTokens(t.Tokens, limit, tabSize, trimWhitespace, true)
+}
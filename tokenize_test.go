@@ -0,0 +1,146 @@
+package stringwrap
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestTokenize tests that the Tokenize function splits a string into the
+// expected tokens and offsets.
+func TestTokenize(t *testing.T) {
+	input := "\x1b[31mhé\x1b[0m\tyo\u00A0u\nbye\x1b[0m"
+	tokens := Tokenize(input).Tokens
+
+	tests := []Token{
+		{
+			Kind:       TokenANSI,
+			Text:       "\x1b[31m",
+			ByteOffset: LineOffset{Start: 0, End: 5},
+			RuneOffset: LineOffset{Start: 0, End: 5},
+		},
+		{
+			Kind:       TokenWord,
+			Text:       "hé",
+			Width:      2,
+			ByteOffset: LineOffset{Start: 5, End: 8},
+			RuneOffset: LineOffset{Start: 5, End: 7},
+		},
+		{
+			Kind:       TokenANSI,
+			Text:       "\x1b[0m",
+			ByteOffset: LineOffset{Start: 8, End: 12},
+			RuneOffset: LineOffset{Start: 7, End: 11},
+		},
+		{
+			Kind:       TokenTab,
+			Text:       "\t",
+			ByteOffset: LineOffset{Start: 12, End: 13},
+			RuneOffset: LineOffset{Start: 11, End: 12},
+		},
+		{
+			Kind:       TokenWord,
+			Text:       "yo\u00A0u",
+			Width:      4,
+			ByteOffset: LineOffset{Start: 13, End: 18},
+			RuneOffset: LineOffset{Start: 12, End: 16},
+			HasNbsp:    true,
+		},
+		{
+			Kind:       TokenHardBreak,
+			Text:       "\n",
+			ByteOffset: LineOffset{Start: 18, End: 19},
+			RuneOffset: LineOffset{Start: 16, End: 17},
+		},
+		{
+			Kind:       TokenWord,
+			Text:       "bye",
+			Width:      3,
+			ByteOffset: LineOffset{Start: 19, End: 22},
+			RuneOffset: LineOffset{Start: 17, End: 20},
+		},
+		{
+			Kind:       TokenANSI,
+			Text:       "\x1b[0m",
+			ByteOffset: LineOffset{Start: 22, End: 26},
+			RuneOffset: LineOffset{Start: 20, End: 24},
+		},
+	}
+
+	assert.Equal(t, len(tests), len(tokens))
+	for idx, tt := range tests {
+		t.Run(fmt.Sprintf("Token Test %d", idx+1), func(t *testing.T) {
+			assert.Equal(t, tt, tokens[idx])
+		})
+	}
+}
+
+// TestTokenize_AdjacentANSI tests that adjacent ANSI escape sequences are
+// each tokenized as an escape sequence, rather than the second being
+// treated as part of the word after it.
+func TestTokenize_AdjacentANSI(t *testing.T) {
+	tokens := Tokenize("\x1b[31m\x1b[1mabc\x1b[0m\x1b[0m d").Tokens
+	tests := []struct {
+		kind  TokenKind
+		text  string
+		width int
+	}{
+		{kind: TokenANSI, text: "\x1b[31m"},
+		{kind: TokenANSI, text: "\x1b[1m"},
+		{kind: TokenWord, text: "abc", width: 3},
+		{kind: TokenANSI, text: "\x1b[0m"},
+		{kind: TokenANSI, text: "\x1b[0m"},
+		{kind: TokenSpace, text: " ", width: 1},
+		{kind: TokenWord, text: "d", width: 1},
+	}
+
+	assert.Equal(t, len(tests), len(tokens))
+	for idx, tt := range tests {
+		t.Run(fmt.Sprintf("Adjacent ANSI Test %d", idx+1), func(t *testing.T) {
+			assert.Equal(t, tt.kind, tokens[idx].Kind)
+			assert.Equal(t, tt.text, tokens[idx].Text)
+			assert.Equal(t, tt.width, tokens[idx].Width)
+		})
+	}
+
+	// the line is measured by its viewable width alone.
+	wrapped, seq, err := StringWrap("\x1b[31m\x1b[1mabc def", 7, 4, true)
+	assert.Nil(t, err)
+	assert.Equal(t, "\x1b[31m\x1b[1mabc def", wrapped)
+	assert.Equal(t, 7, seq.WrappedLines[0].Width)
+}
+
+// TestTokenizedString_Wrap tests that wrapping a tokenized string at a
+// variety of limits produces the same result as StringWrap and
+// StringWrapSplit.
+func TestTokenizedString_Wrap(t *testing.T) {
+	inputs := []string{
+		"The quick brown fox jumps over the lazy dog",
+		"Supercalifragilisticexpialidocious is a long word\nwith 🌟stars",
+		"\x1b[32m\tGreen 🍀 text with ANSI and emojis\x1b[0m alongside  plain",
+		"foo\u2028barbazbaz qux\vness\u00A0here",
+	}
+
+	for idx, input := range inputs {
+		tokenized := Tokenize(input)
+		for limit := 2; limit <= 30; limit++ {
+			for _, trim := range []bool{true, false} {
+				name := fmt.Sprintf("Tokenized Wrap Test %d/%d/%t", idx+1, limit, trim)
+				t.Run(name, func(t *testing.T) {
+					expected, expectedSeq, _ := StringWrap(input, limit, 4, trim)
+					wrapped, seq, err := tokenized.Wrap(limit, 4, trim)
+					assert.Nil(t, err)
+					assert.Equal(t, expected, wrapped)
+					assert.Equal(t, expectedSeq, seq)
+
+					expected, expectedSeq, _ = StringWrapSplit(input, limit, 4, trim)
+					wrapped, seq, err = tokenized.WrapSplit(limit, 4, trim)
+					assert.Nil(t, err)
+					assert.Equal(t, expected, wrapped)
+					assert.Equal(t, expectedSeq, seq)
+				})
+			}
+		}
+	}
+}
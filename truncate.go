@@ -0,0 +1,319 @@
+package stringwrap
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/rivo/uniseg"
+)
+
+// EllipsisPlacement determines where the ellipsis is placed when wrapped
+// text is truncated to a maximum number of lines.
+type EllipsisPlacement int
+
+const (
+	// EllipsisEnd keeps the first lines, ending the last one with the
+	// ellipsis.
+	EllipsisEnd EllipsisPlacement = iota
+	// EllipsisMiddle keeps the first and last lines, joining them with
+	// the ellipsis on the middle line.
+	EllipsisMiddle
+	// EllipsisStart keeps the last lines, starting the first one with
+	// the ellipsis.
+	EllipsisStart
+)
+
+// ansiReset is the escape sequence that resets all styles
+const ansiReset = "\x1b[0m"
+
+// activeStyle returns the SGR escape sequences in the string that are
+// still in effect at its end, since the last time styles were reset
+func activeStyle(str string) string {
+	var style strings.Builder
+	tokenizeFunc(str, nil, func(token Token) {
+		if token.Kind != TokenANSI ||
+			!strings.HasPrefix(token.Text, "\x1b[") ||
+			!strings.HasSuffix(token.Text, "m") {
+			return
+		}
+		if token.Text == ansiReset || token.Text == "\x1b[m" {
+			style.Reset()
+		} else {
+			style.WriteString(token.Text)
+		}
+	})
+	return style.String()
+}
+
+// cutPoints returns the byte offsets in the string at which it can be
+// cut without splitting a grapheme cluster or ANSI escape sequence
+func cutPoints(str string) []int {
+	points := []int{0}
+	tokenizeFunc(str, nil, func(token Token) {
+		if token.Kind == TokenWord {
+			graphemes := uniseg.NewGraphemes(token.Text)
+			for graphemes.Next() {
+				_, end := graphemes.Positions()
+				points = append(points, token.ByteOffset.Start+end)
+			}
+		} else {
+			points = append(points, token.ByteOffset.End)
+		}
+	})
+	return points
+}
+
+// truncation manages state for truncating a wrapped string to a maximum
+// number of lines
+type truncation struct {
+	str      string
+	wrapped  string
+	lines    []string
+	seq      *WrappedStringSeq
+	config   wordWrapConfig
+	ellipsis string
+	width    int
+}
+
+// render lays out a span of the original string on a single line, as it
+// would appear within a wrapped line, returning the text and its width
+func (t *truncation) render(span string) (string, int) {
+	config := t.config
+	config.limit = unboundedLimit
+	wrapped, seq, _ := wrapTokensFrom(tokenize(span, config.measurer), config, newPositions(1, 1, 0, 0))
+	if len(seq.WrappedLines) == 0 {
+		return "", 0
+	}
+	return wrapped, seq.WrappedLines[0].Width
+}
+
+// lineEnd returns the byte offset where the visible text of a wrapped
+// line ends, excluding any hard break
+func (t *truncation) lineEnd(wrappedLine WrappedString) int {
+	return wrappedLine.OrigByteOffset.End - len(wrappedLine.Separator.Text())
+}
+
+// runeOffset converts a byte offset within a wrapped line to a rune
+// offset in the original string
+func (t *truncation) runeOffset(wrappedLine WrappedString, byteIdx int) int {
+	start := wrappedLine.OrigByteOffset.Start
+	return wrappedLine.OrigRuneOffset.Start + utf8.RuneCountInString(t.str[start:byteIdx])
+}
+
+// head returns the longest start of the wrapped line that fits within
+// the width, along with its width and the byte offset where it ends
+func (t *truncation) head(wrappedLine WrappedString, width int) (string, int, int) {
+	start := wrappedLine.OrigByteOffset.Start
+	span := t.str[start:t.lineEnd(wrappedLine)]
+	points := cutPoints(span)
+
+	// the width of the start of a line never decreases as it grows.
+	idx := sort.Search(len(points), func(i int) bool {
+		_, w := t.render(span[:points[i]])
+		return w > width
+	})
+	end := points[max(idx-1, 0)]
+	text, w := t.render(span[:end])
+	return text, w, start + end
+}
+
+// tail returns the longest end of the wrapped line that fits within the
+// width, along with its width and the byte offset where it starts
+func (t *truncation) tail(wrappedLine WrappedString, width int) (string, int, int) {
+	start := wrappedLine.OrigByteOffset.Start
+	span := t.str[start:t.lineEnd(wrappedLine)]
+	points := cutPoints(span)
+
+	// a split word keeps its hyphen at the end of the line, unless split
+	// words are left without one or the hyphen does not fit.
+	hyphenWidth := t.config.hyphenWidth()
+	hyphen := wrappedLine.EndsWithSplitWord && !t.config.omitHyphen &&
+		hyphenWidth <= width
+	if hyphen {
+		width -= hyphenWidth
+	}
+
+	// the width of the end of a line never increases as it shrinks.
+	idx := sort.Search(len(points), func(i int) bool {
+		_, w := t.render(span[points[i]:])
+		return w <= width
+	})
+	begin := points[min(idx, len(points)-1)]
+	text, w := t.render(span[begin:])
+	if hyphen && begin < len(span) {
+		text += "-"
+		w += hyphenWidth
+	}
+	return text, w, start + begin
+}
+
+// truncate keeps at most maxLines of the wrapped lines, replacing the
+// rest with the ellipsis at the given placement
+func (t *truncation) truncate(maxLines int, placement EllipsisPlacement) (
+	string, *WrappedStringSeq,
+) {
+	n := len(t.seq.WrappedLines)
+	if n <= maxLines {
+		return t.wrapped, t.seq
+	}
+
+	// the kept lines are split into full lines before the joint line,
+	// the joint line holding the ellipsis, and full lines after it.
+	var headLines int
+	switch placement {
+	case EllipsisEnd:
+		headLines = maxLines
+	case EllipsisMiddle:
+		headLines = maxLines/2 + 1
+	case EllipsisStart:
+		headLines = 0
+	}
+	tailLine := n - maxLines + max(headLines-1, 0)
+	budget := t.config.limit - t.width - t.config.trailingWidth
+
+	// the joint line starts with the leading marker if the line it
+	// starts from continues a wrapped original line.
+	var text strings.Builder
+	var leadingWidth int
+	from := t.seq.WrappedLines[tailLine]
+	if headLines > 0 {
+		from = t.seq.WrappedLines[headLines-1]
+	}
+	if from.SegmentInOrig > 1 {
+		text.WriteString(t.config.leadingMarker)
+		leadingWidth = t.config.leadingWidth
+		budget -= leadingWidth
+	}
+
+	var joint WrappedString
+	var elided [2]int
+	if headLines > 0 {
+		// the start of the last head line is kept before the ellipsis.
+		headBudget := budget
+		if placement == EllipsisMiddle {
+			headBudget = (budget + 1) / 2
+		}
+		joint = t.seq.WrappedLines[headLines-1]
+		headText, headWidth, headEnd := t.head(joint, headBudget)
+		text.WriteString(headText)
+		text.WriteString(t.ellipsis)
+		budget -= headWidth
+		elided[0] = headEnd
+		elided[1] = len(t.str)
+
+		joint.OrigByteOffset.End = headEnd
+		joint.OrigRuneOffset.End = t.runeOffset(joint, headEnd)
+		joint.Width = headWidth + t.width
+		joint.IsHardBreak = false
+		joint.Separator = SeparatorNone
+		joint.EndsWithSplitWord = false
+		if activeStyle(t.str[:headEnd]) != "" {
+			text.WriteString(ansiReset)
+		}
+	}
+	if placement != EllipsisEnd {
+		// the end of the first tail line is kept after the ellipsis,
+		// with the styles that are in effect where it starts.
+		tailWrapped := t.seq.WrappedLines[tailLine]
+		tailText, tailWidth, tailStart := t.tail(tailWrapped, budget)
+		style := activeStyle(t.str[:tailStart])
+		if headLines == 0 {
+			joint = tailWrapped
+			joint.Width = t.width
+			joint.OrigByteOffset.Start = tailStart
+			joint.OrigRuneOffset.Start = t.runeOffset(tailWrapped, tailStart)
+			text.WriteString(style)
+			text.WriteString(t.ellipsis)
+			elided[0] = 0
+		} else {
+			joint.OrigByteOffset.End = tailWrapped.OrigByteOffset.End
+			joint.OrigRuneOffset.End = tailWrapped.OrigRuneOffset.End
+			joint.IsHardBreak = tailWrapped.IsHardBreak
+			joint.Separator = tailWrapped.Separator
+			joint.LastSegmentInOrig = tailWrapped.LastSegmentInOrig
+			joint.EndsWithSplitWord = tailWrapped.EndsWithSplitWord
+			text.WriteString(style)
+		}
+		text.WriteString(tailText)
+		joint.Width += tailWidth
+		elided[1] = tailStart
+
+		// the joint line ends with the trailing marker if it is still
+		// continued on the next line.
+		if !joint.IsHardBreak && tailLine < n-1 {
+			text.WriteString(t.config.trailingMarker)
+			joint.Width += t.config.trailingWidth
+		}
+	}
+	joint.Width += leadingWidth
+	joint.NotWithinLimit = joint.Width > t.config.limit
+
+	// assemble the kept lines and their metadata, renumbering the lines
+	// that follow the joint line.
+	lines := make([]string, 0, maxLines+1)
+	seq := *t.seq
+	seq.WrappedLines = make([]WrappedString, 0, maxLines)
+	seq.Truncated = true
+	seq.TruncatedByteOffset = LineOffset{Start: elided[0], End: elided[1]}
+	seq.TruncatedRuneOffset = LineOffset{
+		Start: utf8.RuneCountInString(t.str[:elided[0]]),
+		End:   utf8.RuneCountInString(t.str[:elided[1]]),
+	}
+
+	lines = append(lines, t.lines[:max(headLines-1, 0)]...)
+	seq.WrappedLines = append(seq.WrappedLines, t.seq.WrappedLines[:max(headLines-1, 0)]...)
+	lines = append(lines, text.String())
+	seq.WrappedLines = append(seq.WrappedLines, joint)
+	if placement != EllipsisEnd {
+		lines = append(lines, t.lines[tailLine+1:]...)
+		seq.WrappedLines = append(seq.WrappedLines, t.seq.WrappedLines[tailLine+1:]...)
+	}
+
+	var output strings.Builder
+	for i := range seq.WrappedLines {
+		wrappedLine := &seq.WrappedLines[i]
+		wrappedLine.CurLineNum = i + 1
+		start := output.Len()
+		output.WriteString(lines[i])
+		wrappedLine.WrappedByteOffset = LineOffset{Start: start, End: output.Len()}
+
+		// the output ends with a separator only if the last line ends
+		// with a hard break.
+		if i < len(seq.WrappedLines)-1 || wrappedLine.IsHardBreak {
+			output.WriteString(t.config.separator(wrappedLine.Separator))
+		}
+	}
+	return output.String(), &seq
+}
+
+// truncate limits the wrapped string to the maximum number of lines of
+// the wrapper, placing the ellipsis where lines were removed
+func (w Wrapper) truncate(str string, wrapped string, seq *WrappedStringSeq) (
+	string, *WrappedStringSeq, error,
+) {
+	lines := make([]string, len(seq.WrappedLines))
+	for i, wrappedLine := range seq.WrappedLines {
+		lines[i] = wrapped[wrappedLine.WrappedByteOffset.Start:wrappedLine.WrappedByteOffset.End]
+	}
+	t := truncation{
+		str:      str,
+		wrapped:  wrapped,
+		lines:    lines,
+		seq:      seq,
+		config:   w.config(),
+		ellipsis: w.Ellipsis,
+	}
+	// a reflowed line only spans the breaks that were joined.
+	t.config.joinBreaks = w.Reflow
+	// the joint line may hold both continuation markers, which leaves
+	// less room for the ellipsis than the limit.
+	_, t.width = t.render(w.Ellipsis)
+	if t.width >= t.config.continuationLimit() {
+		return "", nil, errors.New("ellipsis must be narrower than the limit less the continuation markers")
+	}
+	wrapped, seq = t.truncate(w.MaxLines, w.EllipsisPlacement)
+	seq.MaxLines, seq.Ellipsis, seq.EllipsisPlacement = w.MaxLines, w.Ellipsis, w.EllipsisPlacement
+	return wrapped, seq, nil
+}
@@ -0,0 +1,263 @@
+package stringwrap
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/mattn/go-runewidth"
+	"github.com/stretchr/testify/assert"
+)
+
+// truncateTestCase is a struct that contains the input string, the
+// wrapper configuration, and the expected truncated output and metadata.
+type truncateTestCase struct {
+	input     string
+	wrapper   Wrapper
+	expected  string
+	truncated LineOffset
+	widths    []int
+}
+
+// wideHyphen measures the hyphen after split words as two columns wide,
+// and everything else with go-runewidth.
+var wideHyphen = WidthFunc(func(str string) int {
+	if str == "-" {
+		return 2
+	}
+	return runewidth.StringWidth(str)
+})
+
+// TestWrapper_Truncate tests wrapping with a maximum number of lines and
+// each ellipsis placement with a variety of test cases.
+func TestWrapper_Truncate(t *testing.T) {
+	const input = "The quick brown fox jumps over the lazy dog and keeps running"
+	wrapper := func(maxLines int, placement EllipsisPlacement) Wrapper {
+		return Wrapper{
+			Limit:             12,
+			TabSize:           4,
+			TrimWhitespace:    true,
+			MaxLines:          maxLines,
+			Ellipsis:          "…",
+			EllipsisPlacement: placement,
+		}
+	}
+
+	tests := []truncateTestCase{
+		{
+			input:     input,
+			wrapper:   wrapper(1, EllipsisEnd),
+			expected:  "The quick…",
+			truncated: LineOffset{Start: 10, End: 61},
+			widths:    []int{10},
+		},
+		{
+			input:     input,
+			wrapper:   wrapper(3, EllipsisEnd),
+			expected:  "The quick\nbrown fox\njumps over…",
+			truncated: LineOffset{Start: 31, End: 61},
+			widths:    []int{9, 9, 11},
+		},
+		{
+			input:     input,
+			wrapper:   wrapper(1, EllipsisMiddle),
+			expected:  "The qu…nning",
+			truncated: LineOffset{Start: 6, End: 56},
+			widths:    []int{12},
+		},
+		{
+			input:     input,
+			wrapper:   wrapper(3, EllipsisMiddle),
+			expected:  "The quick\nbrown…keeps\nrunning",
+			truncated: LineOffset{Start: 16, End: 47},
+			widths:    []int{9, 11, 7},
+		},
+		{
+			input:     input,
+			wrapper:   wrapper(1, EllipsisStart),
+			expected:  "…running",
+			truncated: LineOffset{Start: 0, End: 54},
+			widths:    []int{8},
+		},
+		{
+			input:     input,
+			wrapper:   wrapper(3, EllipsisStart),
+			expected:  "…he lazy dog\nand keeps\nrunning",
+			truncated: LineOffset{Start: 0, End: 32},
+			widths:    []int{12, 9, 7},
+		},
+		{
+			input:    input,
+			wrapper:  wrapper(6, EllipsisEnd),
+			expected: "The quick\nbrown fox\njumps over\nthe lazy dog\nand keeps\nrunning",
+			widths:   []int{9, 9, 10, 12, 9, 7},
+		},
+		{
+			input: "\x1b[31mred text that is\x1b[0m long\nline two\n",
+			wrapper: Wrapper{
+				Limit: 10, TabSize: 4, TrimWhitespace: true, MaxLines: 2, Ellipsis: "...",
+			},
+			expected:  "\x1b[31mred text\nthat is\x1b[0m...",
+			truncated: LineOffset{Start: 26, End: 40},
+			widths:    []int{8, 10},
+		},
+		{
+			input: "\x1b[31mred text that is\x1b[0m long\nline two\n",
+			wrapper: Wrapper{
+				Limit:             10,
+				TabSize:           4,
+				TrimWhitespace:    true,
+				MaxLines:          2,
+				Ellipsis:          "...",
+				EllipsisPlacement: EllipsisStart,
+			},
+			expected:  "...long\nline two\n",
+			truncated: LineOffset{Start: 0, End: 26},
+			widths:    []int{7, 8},
+		},
+		{
+			input: "\x1b[31mSupercalifragilisticexpiali\x1b[1mdocious",
+			wrapper: Wrapper{
+				Limit: 10, TabSize: 4, TrimWhitespace: true, SplitWord: true,
+				MaxLines: 1, Ellipsis: "...",
+			},
+			expected:  "\x1b[31mSuperca...\x1b[0m",
+			truncated: LineOffset{Start: 12, End: 43},
+			widths:    []int{10},
+		},
+		{
+			input: "\x1b[31mSupercalifragilisticexpiali\x1b[1mdocious",
+			wrapper: Wrapper{
+				Limit: 10, TabSize: 4, TrimWhitespace: true, SplitWord: true,
+				MaxLines: 1, Ellipsis: "...", EllipsisPlacement: EllipsisMiddle,
+			},
+			expected:  "\x1b[31mSupe...\x1b[0m\x1b[31m\x1b[1mous",
+			truncated: LineOffset{Start: 9, End: 40},
+			widths:    []int{10},
+		},
+		{
+			input: "\x1b[31mSupercalifragilisticexpiali\x1b[1mdocious",
+			wrapper: Wrapper{
+				Limit: 10, TabSize: 4, TrimWhitespace: true, SplitWord: true,
+				MaxLines: 1, Ellipsis: "...", EllipsisPlacement: EllipsisStart,
+			},
+			expected:  "\x1b[31m\x1b[1m...docious",
+			truncated: LineOffset{Start: 0, End: 36},
+			widths:    []int{10},
+		},
+		{
+			input: "Supercalifragilistic",
+			wrapper: Wrapper{
+				Limit: 8, SplitWord: true, Measurer: wideHyphen,
+				MaxLines: 2, Ellipsis: "…", EllipsisPlacement: EllipsisStart,
+			},
+			expected:  "…lifra-\ngilistic",
+			truncated: LineOffset{Start: 0, End: 7},
+			widths:    []int{8, 8},
+		},
+		{
+			input: "helloworld",
+			wrapper: Wrapper{
+				Limit: 3, SplitWord: true,
+				MaxLines: 3, Ellipsis: "…", EllipsisPlacement: EllipsisMiddle,
+			},
+			expected:  "he-\nl…\nld",
+			truncated: LineOffset{Start: 3, End: 8},
+			widths:    []int{3, 2, 2},
+		},
+		{
+			input: "supercalifragilistic",
+			wrapper: Wrapper{
+				Limit: 4, SplitWord: true,
+				MaxLines: 2, Ellipsis: "...", EllipsisPlacement: EllipsisStart,
+			},
+			expected:  "...\nic",
+			truncated: LineOffset{Start: 0, End: 18},
+			widths:    []int{3, 2},
+		},
+	}
+
+	for idx, tt := range tests {
+		t.Run(fmt.Sprintf("Truncate Test %d", idx+1), func(t *testing.T) {
+			wrapped, seq, err := tt.wrapper.Wrap(tt.input)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.expected, wrapped)
+			assert.Equal(t, tt.truncated != LineOffset{}, seq.Truncated)
+			assert.Equal(t, tt.truncated, seq.TruncatedByteOffset)
+			assert.Equal(t, tt.truncated, seq.TruncatedRuneOffset)
+
+			widths := make([]int, 0, len(seq.WrappedLines))
+			for lineIdx, wrappedLine := range seq.WrappedLines {
+				widths = append(widths, wrappedLine.Width)
+				assert.Equal(t, lineIdx+1, wrappedLine.CurLineNum)
+				assert.False(t, wrappedLine.NotWithinLimit)
+			}
+			assert.Equal(t, tt.widths, widths)
+
+			// the pre-tokenized path truncates identically.
+			tokenWrapped, tokenSeq, err := tt.wrapper.WrapTokens(tt.wrapper.Tokenize(tt.input))
+			assert.Nil(t, err)
+			assert.Equal(t, wrapped, tokenWrapped)
+			assert.Equal(t, seq, tokenSeq)
+		})
+	}
+}
+
+// TestWrapper_TruncateEllipsisTooWide tests that an ellipsis that leaves
+// no room for any text within the limit is rejected.
+func TestWrapper_TruncateEllipsisTooWide(t *testing.T) {
+	wrapper := Wrapper{Limit: 3, TabSize: 4, MaxLines: 1, Ellipsis: "\x1b[2m...\x1b[0m"}
+	_, _, err := wrapper.Wrap("The quick brown fox")
+	assert.NotNil(t, err)
+
+	// the ellipsis must also leave room for the continuation markers.
+	wrapper = Wrapper{
+		Limit: 4, TrimWhitespace: true, SplitWord: true, LeadingMarker: "> ",
+		MaxLines: 2, Ellipsis: "...", EllipsisPlacement: EllipsisMiddle,
+	}
+	_, _, err = wrapper.Wrap("a   hello")
+	assert.NotNil(t, err)
+
+	wrapper.Ellipsis = "…"
+	wrapped, seq, err := wrapper.Wrap("a   hello")
+	assert.Nil(t, err)
+	for _, wrappedLine := range seq.WrappedLines {
+		assert.False(t, wrappedLine.NotWithinLimit, wrapped)
+	}
+}
+
+// TestTruncation_TailOmitHyphen tests that the end of a line ending with
+// a split word is kept without a hyphen when split words are left
+// without one.
+func TestTruncation_TailOmitHyphen(t *testing.T) {
+	const input = "Supercalifragilistic"
+	config := Wrapper{Limit: 8, SplitWord: true}.config()
+	config.omitHyphen = true
+	wrapped, seq, err := wrapTokensFrom(tokenize(input, nil), config, newPositions(1, 1, 0, 0))
+	assert.Nil(t, err)
+	assert.True(t, seq.WrappedLines[0].EndsWithSplitWord)
+
+	trunc := truncation{str: input, wrapped: wrapped, seq: seq, config: config}
+	text, width, start := trunc.tail(seq.WrappedLines[0], 5)
+	assert.Equal(t, "ercal", text)
+	assert.Equal(t, 5, width)
+	assert.Equal(t, 3, start)
+}
+
+// TestTruncation_TailHyphenFits tests that the end of a line ending with
+// a split word is kept without a hyphen when the hyphen does not fit.
+func TestTruncation_TailHyphenFits(t *testing.T) {
+	const input = "Supercalifragilistic"
+	config := Wrapper{Limit: 8, SplitWord: true, Measurer: wideHyphen}.config()
+	wrapped, seq, err := wrapTokensFrom(tokenize(input, config.measurer), config, newPositions(1, 1, 0, 0))
+	assert.Nil(t, err)
+
+	trunc := truncation{str: input, wrapped: wrapped, seq: seq, config: config}
+	text, width, start := trunc.tail(seq.WrappedLines[0], 1)
+	assert.Equal(t, "c", text)
+	assert.Equal(t, 1, width)
+	assert.Equal(t, 5, start)
+
+	text, width, _ = trunc.tail(seq.WrappedLines[0], 3)
+	assert.Equal(t, "c-", text)
+	assert.Equal(t, 3, width)
+}
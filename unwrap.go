@@ -0,0 +1,286 @@
+package stringwrap
+
+import (
+	"sort"
+	"strings"
+)
+
+// unwrapTabSize is the tab size assumed when measuring wrapped lines
+const unwrapTabSize = 8
+
+// inputLine describes a single line of text that was already wrapped
+type inputLine struct {
+	kind lineKind
+	// the byte and rune offsets where the line starts, where its content
+	// starts and ends without the surrounding whitespace, and where it
+	// ends including its line break
+	start        int
+	contentStart int
+	contentEnd   int
+	end          int
+	runeStart    int
+	runeContent  LineOffset
+	runeEnd      int
+	separator    LineSeparator
+	// the width from the start of the line to the end of its content,
+	// the width of its content alone, and the width of its first word
+	width          int
+	contentWidth   int
+	firstWordWidth int
+	multiWord      bool
+}
+
+// measureInputLine measures a line of already-wrapped text
+func measureInputLine(line reflowLine) inputLine {
+	first, last := line.tokens[0], line.tokens[len(line.tokens)-1]
+	input := inputLine{
+		kind:      line.kind,
+		start:     first.ByteOffset.Start,
+		end:       last.ByteOffset.End,
+		runeStart: first.RuneOffset.Start,
+		runeEnd:   last.RuneOffset.End,
+	}
+	if last.Kind == TokenHardBreak {
+		input.separator = separatorKind(last.Text)
+	}
+	input.contentStart, input.contentEnd = input.start, input.start
+	input.runeContent = LineOffset{Start: input.runeStart, End: input.runeStart}
+
+	col, contentStartCol := 0, 0
+	hasContent, afterSpace, inFirstWord := false, false, false
+	for _, token := range line.tokens {
+		switch token.Kind {
+		case TokenWord, TokenANSI:
+			if !hasContent {
+				hasContent, inFirstWord = true, true
+				input.contentStart = token.ByteOffset.Start
+				input.runeContent.Start = token.RuneOffset.Start
+				contentStartCol = col
+			} else if afterSpace && token.Kind == TokenWord {
+				input.multiWord = true
+				inFirstWord = false
+			}
+			if token.Kind == TokenWord {
+				afterSpace = false
+			}
+			if inFirstWord {
+				input.firstWordWidth += token.Width
+			}
+			col += token.Width
+			input.contentEnd = token.ByteOffset.End
+			input.runeContent.End = token.RuneOffset.End
+			input.width = col
+		case TokenSpace:
+			afterSpace = hasContent
+			col += token.Width
+		case TokenTab:
+			afterSpace = hasContent
+			col += unwrapTabSize - col%unwrapTabSize
+		}
+	}
+	input.contentWidth = input.width - contentStartCol
+	return input
+}
+
+// splitInputLines splits already-wrapped text into measured lines
+func splitInputLines(str string) []inputLine {
+	var lines []inputLine
+	for _, line := range reflowLines(tokenize(str, nil)) {
+		lines = append(lines, measureInputLine(line))
+	}
+	return lines
+}
+
+// isParagraphLine returns true if the line is text that may have been
+// wrapped, rather than a blank line or code
+func (l inputLine) isParagraphLine() bool {
+	return l.kind == lineText || l.kind == lineListItem
+}
+
+// fitWidth returns the width the line would have had if the first word
+// of the next line had fit on it, or zero if the lines are never joined
+func fitWidth(lines []inputLine, idx int) int {
+	if idx+1 >= len(lines) || !joins(lines[idx].kind, lines[idx+1].kind) {
+		return 0
+	}
+	return lines[idx].width + 1 + lines[idx+1].firstWordWidth
+}
+
+// inferLimit returns the wrap limit that best explains the line breaks,
+// or zero if the text does not appear to be wrapped.
+//
+// Each limit is scored by the number of breaks after which the next word
+// would not have fit, less the number of lines with more than one word
+// that are wider than the limit, as a wrapper would have broken them.
+func inferLimit(lines []inputLine) int {
+	var candidates []int
+	for _, line := range lines {
+		if line.isParagraphLine() && line.multiWord {
+			candidates = append(candidates, line.width)
+		}
+	}
+	sort.Ints(candidates)
+
+	bestLimit, bestScore := 0, 0
+	for idx, limit := range candidates {
+		if idx > 0 && candidates[idx-1] == limit {
+			continue
+		}
+
+		score := 0
+		for lineIdx, line := range lines {
+			if !line.isParagraphLine() {
+				continue
+			}
+			if line.multiWord && line.width > limit {
+				score -= 1
+			} else if fitWidth(lines, lineIdx) > limit {
+				score += 1
+			}
+		}
+
+		// wider limits are preferred when the score is tied, as they
+		// leave fewer lines wider than the limit.
+		if score > 0 && score >= bestScore {
+			bestLimit, bestScore = limit, score
+		}
+	}
+	return bestLimit
+}
+
+// InferWrapLimit guesses the limit that already-wrapped text was wrapped
+// at, from the widths of its lines and whether the first word of each
+// line would have fit at the end of the line before it. Widths are
+// measured like StringWrap, ignoring ANSI escape sequences.
+//
+// Returns zero if the text does not appear to be wrapped.
+func InferWrapLimit(str string) int {
+	return inferLimit(splitInputLines(str))
+}
+
+// unwrapJoin records a line break that was joined when unwrapping, along
+// with the whitespace around it, which are replaced by a single space
+type unwrapJoin struct {
+	byteIdx  int
+	runeIdx  int
+	origByte LineOffset
+	origRune LineOffset
+}
+
+// UnwrappedSeq holds the lines of unwrapped text, along with the mapping
+// back to the already-wrapped input.
+type UnwrappedSeq struct {
+	// WrappedStringSeq holds each unwrapped line, in which OrigLineNum
+	// is the first input line it was joined from, and the offsets span
+	// every input line joined into it. Limit is the inferred limit.
+	*WrappedStringSeq
+	joins []unwrapJoin
+}
+
+// OrigByteOffset maps a byte offset in the unwrapped text to the byte
+// offset in the wrapped input. The space that replaced a line break maps
+// to the start of the whitespace it replaced.
+func (s *UnwrappedSeq) OrigByteOffset(byteIdx int) int {
+	idx := sort.Search(len(s.joins), func(i int) bool { return s.joins[i].byteIdx > byteIdx })
+	if idx == 0 {
+		return byteIdx
+	}
+	join := s.joins[idx-1]
+	if byteIdx == join.byteIdx {
+		return join.origByte.Start
+	}
+	return join.origByte.End + byteIdx - join.byteIdx - 1
+}
+
+// OrigRuneOffset maps a rune offset in the unwrapped text to the rune
+// offset in the wrapped input, like OrigByteOffset.
+func (s *UnwrappedSeq) OrigRuneOffset(runeIdx int) int {
+	idx := sort.Search(len(s.joins), func(i int) bool { return s.joins[i].runeIdx > runeIdx })
+	if idx == 0 {
+		return runeIdx
+	}
+	join := s.joins[idx-1]
+	if runeIdx == join.runeIdx {
+		return join.origRune.Start
+	}
+	return join.origRune.End + runeIdx - join.runeIdx - 1
+}
+
+// Unwrap joins the lines of text that another tool already wrapped back
+// into paragraphs, so that it can be wrapped again at a different limit.
+//
+// The wrap limit is inferred with InferWrapLimit, and a line break is
+// joined if the first word of the next line would not have fit within
+// the limit. Blank lines, list items and code blocks are kept as they
+// are, like Reflow. The whitespace around a joined break is replaced by
+// a single space, and all other text is unchanged.
+//
+// Returns the unwrapped text and a sequence describing each unwrapped
+// line, which maps offsets in the unwrapped text back to the input.
+func Unwrap(str string) (string, *UnwrappedSeq) {
+	lines := splitInputLines(str)
+	limit := inferLimit(lines)
+	seq := &UnwrappedSeq{WrappedStringSeq: &WrappedStringSeq{
+		TabSize: unwrapTabSize, Limit: limit,
+	}}
+
+	var unwrapped strings.Builder
+	runeIdx := 0
+	var paragraph WrappedString
+	for idx, line := range lines {
+		joined := idx > 0 && limit > 0 && fitWidth(lines, idx-1) > limit
+		if joined {
+			// the whitespace between the content of the lines, and the
+			// break, are replaced by a single space.
+			prev := lines[idx-1]
+			seq.joins = append(seq.joins, unwrapJoin{
+				byteIdx:  unwrapped.Len(),
+				runeIdx:  runeIdx,
+				origByte: LineOffset{Start: prev.contentEnd, End: line.contentStart},
+				origRune: LineOffset{Start: prev.runeContent.End, End: line.runeContent.Start},
+			})
+			unwrapped.WriteByte(' ')
+			runeIdx += 1
+			paragraph.Width += 1 + line.contentWidth
+		} else {
+			if idx > 0 {
+				seq.appendWrappedSeq(paragraph)
+			}
+			paragraph = WrappedString{
+				CurLineNum:        len(seq.WrappedLines) + 1,
+				OrigLineNum:       idx + 1,
+				OrigByteOffset:    LineOffset{Start: line.start},
+				OrigRuneOffset:    LineOffset{Start: line.runeStart},
+				WrappedByteOffset: LineOffset{Start: unwrapped.Len()},
+				SegmentInOrig:     1,
+				LastSegmentInOrig: true,
+				Width:             line.width,
+			}
+		}
+
+		// the text of the line is written as it is, except for the
+		// whitespace around joined breaks.
+		start, runeStart := line.start, line.runeStart
+		if joined {
+			start, runeStart = line.contentStart, line.runeContent.Start
+		}
+		end, runeEnd := line.end, line.runeEnd
+		separator := line.separator
+		if idx+1 < len(lines) && limit > 0 && fitWidth(lines, idx) > limit {
+			end, runeEnd = line.contentEnd, line.runeContent.End
+			separator = SeparatorNone
+		}
+		unwrapped.WriteString(str[start:end])
+		runeIdx += runeEnd - runeStart
+
+		paragraph.OrigByteOffset.End = line.end
+		paragraph.OrigRuneOffset.End = line.runeEnd
+		paragraph.WrappedByteOffset.End = unwrapped.Len() - len(separator.Text())
+		paragraph.IsHardBreak = line.separator != SeparatorNone
+		paragraph.Separator = line.separator
+	}
+	if len(lines) > 0 {
+		seq.appendWrappedSeq(paragraph)
+	}
+	return unwrapped.String(), seq
+}
@@ -0,0 +1,130 @@
+package stringwrap
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// unwrapTestCase is a struct that contains the already-wrapped input,
+// and the expected inferred limit, unwrapped string and original line
+// numbers.
+type unwrapTestCase struct {
+	input    string
+	limit    int
+	expected string
+	origLine []int
+}
+
+// TestUnwrap tests unwrapping already-wrapped text with a variety of test
+// cases.
+func TestUnwrap(t *testing.T) {
+	tests := []unwrapTestCase{
+		{
+			input:    "The quick brown fox\njumps over the lazy\ndog.",
+			limit:    19,
+			expected: "The quick brown fox jumps over the lazy dog.",
+			origLine: []int{1},
+		},
+		{
+			input:    "The quick brown fox\njumps over the lazy\ndog.\n\nShort line.\nAnother line.\n",
+			limit:    19,
+			expected: "The quick brown fox jumps over the lazy dog.\n\nShort line.\nAnother line.\n",
+			origLine: []int{1, 4, 5, 6},
+		},
+		{
+			input:    "- a list item that\ngoes on for a while\n- second item\n",
+			limit:    19,
+			expected: "- a list item that goes on for a while\n- second item\n",
+			origLine: []int{1, 3},
+		},
+		{
+			input:    "The quick brown fox \n  jumps over the lazy\ndog.",
+			limit:    21,
+			expected: "The quick brown fox jumps over the lazy dog.",
+			origLine: []int{1},
+		},
+		{
+			input:    "Example:\n\n    code line one is long\n    code two\nafter",
+			limit:    0,
+			expected: "Example:\n\n    code line one is long\n    code two\nafter",
+			origLine: []int{1, 2, 3, 4, 5},
+		},
+		{
+			input:    "one two\nthree four five six seven",
+			limit:    0,
+			expected: "one two\nthree four five six seven",
+			origLine: []int{1, 2},
+		},
+		{
+			input:    "",
+			limit:    0,
+			expected: "",
+		},
+	}
+
+	for idx, test := range tests {
+		t.Run(fmt.Sprintf("Unwrap Test %d", idx+1), func(t *testing.T) {
+			assert.Equal(t, test.limit, InferWrapLimit(test.input))
+
+			unwrapped, seq := Unwrap(test.input)
+			assert.Equal(t, test.expected, unwrapped)
+			assert.Equal(t, test.limit, seq.Limit)
+
+			var origLine []int
+			for idx, wrappedLine := range seq.WrappedLines {
+				origLine = append(origLine, wrappedLine.OrigLineNum)
+				offset := wrappedLine.WrappedByteOffset
+				assert.Equal(t, strings.Split(test.expected, "\n")[idx], unwrapped[offset.Start:offset.End])
+			}
+			assert.Equal(t, test.origLine, origLine)
+		})
+	}
+}
+
+// TestInferWrapLimit tests that lines with a single long word and lines
+// that end paragraphs early do not throw off the inferred limit.
+func TestInferWrapLimit(t *testing.T) {
+	text := "Lorem ipsum dolor sit amet,\nconsectetur adipiscing elit,\n" +
+		"sed do eiusmod tempor\nincididunt ut labore et dolore\n" +
+		"magna aliqua.\n\nhttps://example.com/a/very/long/link/that/is/not/wrapped\n" +
+		"Ut enim ad minim veniam, quis\nnostrud exercitation ullamco\n" +
+		"laboris nisi ut aliquip ex ea\ncommodo consequat."
+	assert.Equal(t, 30, InferWrapLimit(text))
+
+	wrapped, _, err := StringWrap(text, 30, 4, true)
+	assert.NoError(t, err)
+	assert.Equal(t, 30, InferWrapLimit(wrapped))
+
+	colored := "\x1b[1mThe quick\x1b[0m brown fox\njumps over the lazy\ndog."
+	assert.Equal(t, 19, InferWrapLimit(colored))
+}
+
+// TestUnwrappedSeq_OrigOffset tests mapping offsets in the unwrapped text
+// back to the wrapped input.
+func TestUnwrappedSeq_OrigOffset(t *testing.T) {
+	input := "The quick brown fox \n  jumps over the lazy\ndog é."
+	unwrapped, seq := Unwrap(input)
+	assert.Equal(t, "The quick brown fox jumps over the lazy dog é.", unwrapped)
+
+	// every byte other than the joining spaces maps back to the same byte
+	for idx := 0; idx < len(unwrapped); idx++ {
+		origIdx := seq.OrigByteOffset(idx)
+		if unwrapped[idx] != ' ' {
+			assert.Equal(t, unwrapped[idx], input[origIdx])
+		}
+	}
+
+	assert.Equal(t, 19, seq.OrigByteOffset(19))
+	assert.Equal(t, 23, seq.OrigByteOffset(20))
+	assert.Equal(t, 42, seq.OrigByteOffset(39))
+	assert.Equal(t, 43, seq.OrigByteOffset(40))
+	assert.Equal(t, 47, seq.OrigByteOffset(44))
+	assert.Equal(t, 48, seq.OrigRuneOffset(45))
+
+	assert.Equal(t, LineOffset{Start: 0, End: len(input)}, seq.WrappedLines[0].OrigByteOffset)
+	assert.Equal(t, 46, seq.WrappedLines[0].Width)
+	assert.False(t, seq.WrappedLines[0].IsHardBreak)
+}
@@ -0,0 +1,49 @@
+package stringwrap
+
+// WhiteSpace determines how whitespace is processed while wrapping,
+// following the white-space property of CSS.
+type WhiteSpace int
+
+const (
+	// WhiteSpaceDefault keeps runs of whitespace and hard breaks, and
+	// strips whitespace at the start and end of each line if the
+	// wrapper trims whitespace, as StringWrap does.
+	WhiteSpaceDefault WhiteSpace = iota
+	// WhiteSpaceNormal collapses runs of spaces, tabs and hard breaks
+	// into a single space, which is removed at the start and end of
+	// each line.
+	WhiteSpaceNormal
+	// WhiteSpacePre keeps every space, tab and hard break, and never
+	// wraps lines.
+	WhiteSpacePre
+	// WhiteSpacePreWrap keeps every space, tab and hard break. Spaces at
+	// the end of a line hang past the limit instead of wrapping, and do
+	// not count towards the width of the line.
+	WhiteSpacePreWrap
+	// WhiteSpacePreLine collapses runs of spaces and tabs into a single
+	// space, which is removed at the start and end of each line, but
+	// keeps hard breaks.
+	WhiteSpacePreLine
+	// WhiteSpaceBreakSpaces keeps every space, tab and hard break, and
+	// wraps lines between spaces, so spaces always take up room.
+	WhiteSpaceBreakSpaces
+)
+
+// apply sets the options of the configuration that the whitespace mode
+// determines
+func (ws WhiteSpace) apply(config *wordWrapConfig) {
+	switch ws {
+	case WhiteSpaceNormal, WhiteSpacePreLine:
+		config.trimWhitespace = true
+		config.collapseSpaces = true
+		config.collapseBreaks = ws == WhiteSpaceNormal
+	case WhiteSpacePre:
+		config.trimWhitespace = false
+		config.noWrap = true
+	case WhiteSpacePreWrap:
+		config.trimWhitespace = false
+		config.hangSpaces = true
+	case WhiteSpaceBreakSpaces:
+		config.trimWhitespace = false
+	}
+}
@@ -0,0 +1,144 @@
+package stringwrap
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// whiteSpaceTestCase is a struct that contains the whitespace mode, and
+// the expected wrapped string, widths and byte offsets.
+type whiteSpaceTestCase struct {
+	whiteSpace  WhiteSpace
+	expected    string
+	widths      []int
+	byteOffsets []LineOffset
+}
+
+// TestWrapper_WhiteSpace tests each CSS whitespace mode with a variety of
+// test cases.
+func TestWrapper_WhiteSpace(t *testing.T) {
+	input := "  The  quick\tbrown \n\n fox   jumps  over\nthe lazy dog  "
+	tests := []whiteSpaceTestCase{
+		{
+			whiteSpace: WhiteSpaceNormal,
+			expected:   "The quick\nbrown fox\njumps over\nthe lazy\ndog",
+			widths:     []int{9, 9, 10, 8, 3},
+			byteOffsets: []LineOffset{
+				{Start: 0, End: 13}, {Start: 13, End: 28}, {Start: 28, End: 39},
+				{Start: 39, End: 49}, {Start: 49, End: 54},
+			},
+		},
+		{
+			whiteSpace: WhiteSpacePre,
+			expected:   "  The  quick    brown \n\n fox   jumps  over\nthe lazy dog  ",
+			widths:     []int{22, 0, 18, 14},
+			byteOffsets: []LineOffset{
+				{Start: 0, End: 20}, {Start: 20, End: 21}, {Start: 21, End: 40}, {Start: 40, End: 54},
+			},
+		},
+		{
+			whiteSpace: WhiteSpacePreWrap,
+			expected:   "  The  \nquick   \nbrown \n\n fox   \njumps  \nover\nthe lazy \ndog  ",
+			widths:     []int{5, 5, 5, 0, 4, 5, 4, 8, 3},
+			byteOffsets: []LineOffset{
+				{Start: 0, End: 7}, {Start: 7, End: 13}, {Start: 13, End: 20},
+				{Start: 20, End: 21}, {Start: 21, End: 28}, {Start: 28, End: 35},
+				{Start: 35, End: 40}, {Start: 40, End: 49}, {Start: 49, End: 54},
+			},
+		},
+		{
+			whiteSpace: WhiteSpacePreLine,
+			expected:   "The quick\nbrown\n\nfox jumps\nover\nthe lazy\ndog",
+			widths:     []int{9, 5, 0, 9, 4, 8, 3},
+			byteOffsets: []LineOffset{
+				{Start: 0, End: 13}, {Start: 13, End: 20}, {Start: 20, End: 21},
+				{Start: 21, End: 35}, {Start: 35, End: 40}, {Start: 40, End: 49},
+				{Start: 49, End: 54},
+			},
+		},
+		{
+			whiteSpace: WhiteSpaceBreakSpaces,
+			expected:   "  The  \nquick   \nbrown \n\n fox   \njumps  \nover\nthe lazy \ndog  ",
+			widths:     []int{7, 8, 6, 0, 7, 7, 4, 9, 5},
+			byteOffsets: []LineOffset{
+				{Start: 0, End: 7}, {Start: 7, End: 13}, {Start: 13, End: 20},
+				{Start: 20, End: 21}, {Start: 21, End: 28}, {Start: 28, End: 35},
+				{Start: 35, End: 40}, {Start: 40, End: 49}, {Start: 49, End: 54},
+			},
+		},
+	}
+
+	for idx, tt := range tests {
+		t.Run(fmt.Sprintf("White Space Test %d", idx+1), func(t *testing.T) {
+			wrapper := Wrapper{Limit: 10, TabSize: 4, WhiteSpace: tt.whiteSpace}
+			wrapped, seq, err := wrapper.Wrap(input)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.expected, wrapped)
+
+			widths := make([]int, 0, len(seq.WrappedLines))
+			byteOffsets := make([]LineOffset, 0, len(seq.WrappedLines))
+			for _, wrappedLine := range seq.WrappedLines {
+				widths = append(widths, wrappedLine.Width)
+				byteOffsets = append(byteOffsets, wrappedLine.OrigByteOffset)
+			}
+			assert.Equal(t, tt.widths, widths)
+			assert.Equal(t, tt.byteOffsets, byteOffsets)
+
+			measurement, err := wrapper.Measure(input, true)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.widths, measurement.Widths)
+		})
+	}
+}
+
+// TestWrapper_WhiteSpaceCollapse tests that whitespace collapses across
+// escape sequences and is independent of TrimWhitespace.
+func TestWrapper_WhiteSpaceCollapse(t *testing.T) {
+	wrapper := Wrapper{Limit: 10, TabSize: 4, WhiteSpace: WhiteSpaceNormal}
+	wrapped, _, err := wrapper.Wrap("a \x1b[1m  b\x1b[0m   c")
+	assert.Nil(t, err)
+	assert.Equal(t, "a \x1b[1mb\x1b[0m c", wrapped)
+
+	wrapper.TrimWhitespace = true
+	trimmed, _, err := wrapper.Wrap("a \x1b[1m  b\x1b[0m   c")
+	assert.Nil(t, err)
+	assert.Equal(t, wrapped, trimmed)
+
+	assert.Equal(t, 5, wrapper.MinContentWidth("  The  quick\tbrown \n\n fox"))
+	assert.Equal(t, 19, wrapper.MaxContentWidth("  The  quick\tbrown \n\n fox"))
+
+	wrapper.MaxLines = 2
+	wrapper.Ellipsis = "…"
+	wrapped, seq, err := wrapper.Wrap("  The  quick\tbrown \n\n fox   jumps  over")
+	assert.Nil(t, err)
+	assert.Equal(t, "The quick\nbrown fox…", wrapped)
+	assert.Equal(t, LineOffset{Start: 28, End: 39}, seq.TruncatedByteOffset)
+
+	wrapper.WhiteSpace = WhiteSpacePre
+	assert.Equal(t, 22, wrapper.MinContentWidth("  The  quick\tbrown \n\n fox"))
+}
+
+// TestWrapper_WhiteSpaceHang tests that spaces hang past the limit under
+// WhiteSpacePreWrap instead of wrapping onto their own line.
+func TestWrapper_WhiteSpaceHang(t *testing.T) {
+	tests := []struct {
+		limit    int
+		expected string
+	}{
+		{limit: 5, expected: "hello   \nworld"},
+		{limit: 6, expected: "hello   \nworld"},
+		{limit: 8, expected: "hello   \nworld"},
+	}
+
+	for idx, tt := range tests {
+		t.Run(fmt.Sprintf("Hang Test %d", idx+1), func(t *testing.T) {
+			wrapper := Wrapper{Limit: tt.limit, WhiteSpace: WhiteSpacePreWrap}
+			wrapped, seq, err := wrapper.Wrap("hello   world")
+			assert.Nil(t, err)
+			assert.Equal(t, tt.expected, wrapped)
+			assert.Equal(t, 5, seq.WrappedLines[0].Width)
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package stringwrap
+
+import (
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mattn/go-runewidth"
+)
+
+const (
+	// textPresentation is variation selector 15 (VS15), which requests
+	// that the preceding character is rendered as text.
+	textPresentation = '\uFE0E'
+	// emojiPresentation is variation selector 16 (VS16), which requests
+	// that the preceding character is rendered as an emoji.
+	emojiPresentation = '\uFE0F'
+)
+
+// WidthOptions configures how the Measurer created by NewMeasurer
+// measures grapheme clusters, to match the terminal the text is shown in.
+type WidthOptions struct {
+	// AmbiguousWide measures East Asian ambiguous-width characters, such
+	// as box drawing, Greek letters and "…", as two columns wide, as
+	// terminals in CJK locales do.
+	AmbiguousWide bool
+	// EmojiWidth is the width of clusters with emoji presentation. Zero
+	// keeps the default width of two columns.
+	EmojiWidth int
+	// VariationSelectors measures clusters ending with VS15 (U+FE0E) as
+	// one column wide text, and clusters with VS16 (U+FE0F) as emoji,
+	// rather than by their base character alone.
+	VariationSelectors bool
+}
+
+// DetectWidthOptions returns the width options for the current locale.
+// Ambiguous-width characters are wide if RUNEWIDTH_EASTASIAN is "1", or
+// if it is unset and LC_ALL, LC_CTYPE or LANG names a CJK locale.
+func DetectWidthOptions() WidthOptions {
+	opts := WidthOptions{VariationSelectors: true}
+	if env := os.Getenv("RUNEWIDTH_EASTASIAN"); env != "" {
+		opts.AmbiguousWide = env == "1"
+	} else {
+		opts.AmbiguousWide = runewidth.IsEastAsian()
+	}
+	return opts
+}
+
+// conditionMeasurer measures grapheme clusters with its own go-runewidth
+// condition, so that the options never affect other wrappers
+type conditionMeasurer struct {
+	opts      WidthOptions
+	condition *runewidth.Condition
+	narrow    *runewidth.Condition
+}
+
+// NewMeasurer creates a Measurer that measures grapheme clusters with
+// go-runewidth according to the options. Unlike the go-runewidth
+// package settings, the options only apply to wrappers that use the
+// returned Measurer.
+func NewMeasurer(opts WidthOptions) Measurer {
+	return &conditionMeasurer{
+		opts: opts,
+		condition: &runewidth.Condition{
+			EastAsianWidth: opts.AmbiguousWide, StrictEmojiNeutral: true,
+		},
+		narrow: &runewidth.Condition{StrictEmojiNeutral: true},
+	}
+}
+
+// isPictograph returns true if the rune is in one of the blocks of
+// symbols and pictographs that emoji are drawn from
+func isPictograph(r rune) bool {
+	return (r >= 0x2300 && r <= 0x27BF) ||
+		(r >= 0x2B00 && r <= 0x2BFF) ||
+		(r >= 0x1F000 && r <= 0x1FAFF)
+}
+
+// isEmoji returns true if the cluster is presented as an emoji, which is
+// when its first rune is a wide pictograph or it requests emoji
+// presentation with VS16
+func (m *conditionMeasurer) isEmoji(cluster string) bool {
+	r, _ := utf8.DecodeRuneInString(cluster)
+	if !isPictograph(r) {
+		return false
+	}
+	if m.opts.VariationSelectors && strings.ContainsRune(cluster, emojiPresentation) {
+		return true
+	}
+	return m.narrow.RuneWidth(r) == 2
+}
+
+// StringWidth returns the viewable width of the grapheme cluster.
+func (m *conditionMeasurer) StringWidth(cluster string) int {
+	width := m.condition.StringWidth(cluster)
+	if width == 0 {
+		return 0
+	}
+	if m.opts.VariationSelectors && strings.ContainsRune(cluster, textPresentation) {
+		return 1
+	}
+	if m.isEmoji(cluster) {
+		if m.opts.EmojiWidth > 0 {
+			return m.opts.EmojiWidth
+		}
+		return 2
+	}
+	return width
+}
@@ -0,0 +1,102 @@
+package stringwrap
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// widthOptionsTestCase is a struct that contains a grapheme cluster, the
+// width options, and the expected width of the cluster.
+type widthOptionsTestCase struct {
+	cluster  string
+	opts     WidthOptions
+	expected int
+}
+
+// TestNewMeasurer tests the widths measured with each of the width
+// options with a variety of test cases.
+func TestNewMeasurer(t *testing.T) {
+	ambiguous := WidthOptions{AmbiguousWide: true}
+	selectors := WidthOptions{VariationSelectors: true}
+
+	tests := []widthOptionsTestCase{
+		{cluster: "…", opts: WidthOptions{}, expected: 1},
+		{cluster: "…", opts: ambiguous, expected: 2},
+		{cluster: "─", opts: WidthOptions{}, expected: 1},
+		{cluster: "─", opts: ambiguous, expected: 2},
+		{cluster: "α", opts: ambiguous, expected: 2},
+		{cluster: "a", opts: ambiguous, expected: 1},
+		{cluster: "日", opts: WidthOptions{}, expected: 2},
+		{cluster: "🌟", opts: WidthOptions{}, expected: 2},
+		{cluster: "🌟", opts: WidthOptions{EmojiWidth: 1}, expected: 1},
+		{cluster: "👩\u200D💻", opts: WidthOptions{EmojiWidth: 1}, expected: 1},
+		{cluster: "☆", opts: WidthOptions{AmbiguousWide: true, EmojiWidth: 1}, expected: 2},
+		{cluster: "❤\uFE0F", opts: WidthOptions{}, expected: 1},
+		{cluster: "❤\uFE0F", opts: selectors, expected: 2},
+		{cluster: "❤\uFE0F", opts: WidthOptions{VariationSelectors: true, EmojiWidth: 1}, expected: 1},
+		{cluster: "🌟\uFE0E", opts: WidthOptions{}, expected: 2},
+		{cluster: "🌟\uFE0E", opts: selectors, expected: 1},
+		{cluster: "\u200B", opts: selectors, expected: 0},
+	}
+
+	for idx, tt := range tests {
+		t.Run(fmt.Sprintf("Width Options Test %d", idx+1), func(t *testing.T) {
+			assert.Equal(t, tt.expected, NewMeasurer(tt.opts).StringWidth(tt.cluster))
+		})
+	}
+}
+
+// TestNewMeasurer_Wrap tests that the width options of one wrapper apply
+// to its wrapped lines without affecting other wrappers.
+func TestNewMeasurer_Wrap(t *testing.T) {
+	input := "┌──┐ α…β ❤\uFE0F"
+	narrow := Wrapper{Limit: 12, TabSize: 4, TrimWhitespace: true}
+	wide := Wrapper{
+		Limit: 12, TabSize: 4, TrimWhitespace: true,
+		Measurer: NewMeasurer(WidthOptions{AmbiguousWide: true, VariationSelectors: true}),
+	}
+
+	wrapped, seq, err := wide.Wrap(input)
+	assert.Nil(t, err)
+	assert.Equal(t, "┌──┐\nα…β ❤\uFE0F", wrapped)
+	assert.Equal(t, 8, seq.WrappedLines[0].Width)
+	assert.Equal(t, 9, seq.WrappedLines[1].Width)
+
+	wrapped, seq, err = narrow.Wrap(input)
+	assert.Nil(t, err)
+	assert.Equal(t, input, wrapped)
+	assert.Equal(t, 10, seq.WrappedLines[0].Width)
+}
+
+// TestDetectWidthOptions tests that ambiguous-width handling is detected
+// from the environment with a variety of test cases.
+func TestDetectWidthOptions(t *testing.T) {
+	tests := []struct {
+		eastAsian string
+		lcAll     string
+		lang      string
+		expected  bool
+	}{
+		{eastAsian: "1", lang: "en_US.UTF-8", expected: true},
+		{eastAsian: "0", lang: "ja_JP.UTF-8", expected: false},
+		{lcAll: "ja_JP.UTF-8", lang: "en_US.UTF-8", expected: true},
+		{lang: "zh_CN.UTF-8", expected: true},
+		{lang: "ja_JP.eucJP", expected: true},
+		{lang: "en_US.UTF-8", expected: false},
+		{lang: "C", expected: false},
+	}
+
+	for idx, tt := range tests {
+		t.Run(fmt.Sprintf("Detect Width Options Test %d", idx+1), func(t *testing.T) {
+			t.Setenv("RUNEWIDTH_EASTASIAN", tt.eastAsian)
+			t.Setenv("LC_ALL", tt.lcAll)
+			t.Setenv("LC_CTYPE", "")
+			t.Setenv("LANG", tt.lang)
+			opts := DetectWidthOptions()
+			assert.Equal(t, tt.expected, opts.AmbiguousWide)
+			assert.True(t, opts.VariationSelectors)
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package stringwrap
+
+// Wrapper holds the configuration used to wrap strings. It exposes the
+// same behaviour as StringWrap and StringWrapSplit, along with options
+// that are not available through those functions.
+//
+// The zero value is not usable, as Limit must be greater than one.
+type Wrapper struct {
+	// Limit is the maximum viewable width allowed per line.
+	Limit int
+	// TabSize defines how many spaces a tab character expands to.
+	TabSize int
+	// TabStops is a list of increasing columns that tabs expand to,
+	// counted from the start of each line, such as []int{8, 20, 32}.
+	// Past the last of them, tab stops continue every TabSize columns.
+	TabStops []int
+	// KeepTabs writes each tab as a tab rather than as spaces, while
+	// still measuring it as the width it expands to. Tabs are always
+	// expanded with CarriageReturnOverwrite, as the text written over
+	// them is measured by the columns they cover.
+	KeepTabs bool
+	// TrimWhitespace strips leading and trailing whitespace from each
+	// wrapped line.
+	TrimWhitespace bool
+	// SplitWord allows words to be split across lines if they exceed
+	// the limit.
+	SplitWord bool
+	// Measurer measures the viewable width of grapheme clusters and
+	// whitespace. If nil, widths are measured with go-runewidth.
+	Measurer Measurer
+	// Metric is the unit that Limit, and the width of each wrapped
+	// line, are measured in. Metrics other than MetricWidth ignore
+	// Measurer. ANSI escape sequences are never counted.
+	Metric Metric
+	// WhiteSpace determines how whitespace is processed, following CSS.
+	// Modes other than WhiteSpaceDefault ignore TrimWhitespace.
+	WhiteSpace WhiteSpace
+	// Reflow joins the lines of each paragraph before wrapping, so text
+	// that was already wrapped at a narrower limit is filled out again.
+	// Blank lines separate paragraphs, list items start new ones, and
+	// indented and fenced code blocks are kept as they are.
+	Reflow bool
+	// CarriageReturn determines how a carriage return that is not
+	// followed by a line feed is handled. A carriage return followed by
+	// a line feed is always a single hard break.
+	CarriageReturn CarriageReturn
+	// LineBreak is written after each wrapped line, such as "\r\n" or
+	// "<br>". Empty means "\n".
+	LineBreak string
+	// SoftBreak is written after lines that were wrapped rather than
+	// ending at a hard break, such as "\\\n" to continue a shell
+	// command. Empty means LineBreak.
+	SoftBreak string
+	// PreserveSeparators ends each line that ends at a hard break with
+	// the original line separator, such as "\r\n", rather than
+	// LineBreak.
+	PreserveSeparators bool
+	// LeadingMarker is written at the start of each line that continues
+	// a wrapped original line, such as "↪ ". TrailingMarker is written at
+	// the end of each line that is continued on the next, such as "↩" or
+	// " \\". Both may contain ANSI escape sequences and count towards
+	// Limit, and the width of TrailingMarker is left free on every line.
+	// They are written alongside any hyphen after a split word.
+	LeadingMarker  string
+	TrailingMarker string
+	// Workers is the number of goroutines used to wrap the original
+	// lines of large strings concurrently. Zero or one wraps the string
+	// sequentially. The result is identical either way.
+	Workers int
+	// MaxLines is the maximum number of wrapped lines. Lines beyond it
+	// are removed and replaced by the ellipsis. Zero means no maximum.
+	MaxLines int
+	// Ellipsis is written where lines were removed, such as "…". It may
+	// contain ANSI escape sequences and must be narrower than Limit less
+	// the widths of the continuation markers.
+	Ellipsis string
+	// EllipsisPlacement determines which lines are kept when truncating
+	// and where the ellipsis is placed.
+	EllipsisPlacement EllipsisPlacement
+}
+
+// measurer returns the Measurer for the metric of the wrapper
+func (w Wrapper) measurer() Measurer {
+	if w.Metric != MetricWidth {
+		return w.Metric.measurer()
+	}
+	return w.Measurer
+}
+
+// config converts the wrapper into the internal configuration
+func (w Wrapper) config() wordWrapConfig {
+	config := wordWrapConfig{
+		limit:              w.Limit,
+		tabSize:            w.TabSize,
+		tabStops:           w.TabStops,
+		keepTabs:           w.KeepTabs && w.CarriageReturn != CarriageReturnOverwrite,
+		trimWhitespace:     w.TrimWhitespace,
+		splitWord:          w.SplitWord,
+		measurer:           w.measurer(),
+		carriageReturn:     w.CarriageReturn,
+		lineBreak:          w.LineBreak,
+		softBreak:          w.SoftBreak,
+		preserveSeparators: w.PreserveSeparators,
+	}
+	config.setMarkers(w.LeadingMarker, w.TrailingMarker)
+	w.WhiteSpace.apply(&config)
+	return config
+}
+
+// Wrap wraps the input string using the configuration of the wrapper.
+//
+// Returns the wrapped string and a metadata sequence describing each
+// wrapped line, exactly as StringWrap and StringWrapSplit do. If MaxLines
+// is set, the lines beyond it are replaced by the ellipsis, and the
+// metadata sequence records the text that was removed.
+func (w Wrapper) Wrap(str string) (string, *WrappedStringSeq, error) {
+	var wrapped string
+	var seq *WrappedStringSeq
+	var err error
+	// collapsing hard breaks joins the original lines, so they cannot be
+	// wrapped independently.
+	if w.Reflow {
+		wrapped, seq, err = wrapReflowTokens(tokenize(str, w.measurer()), w.config())
+	} else if w.Workers > 1 && w.WhiteSpace != WhiteSpaceNormal &&
+		w.CarriageReturn == CarriageReturnBreak {
+		wrapped, seq, err = wrapParallel(str, w.config(), w.Workers)
+	} else {
+		wrapped, seq, err = wrapTokensFrom(
+			tokenize(str, w.measurer()), w.config(), newPositions(1, 1, 0, 0),
+		)
+	}
+	if err != nil || w.MaxLines < 1 {
+		w.record(seq)
+		return wrapped, seq, err
+	}
+	wrapped, seq, err = w.truncate(str, wrapped, seq)
+	w.record(seq)
+	return wrapped, seq, err
+}
+
+// record records the options of the wrapper that are not held by the
+// internal configuration in the metadata sequence
+func (w Wrapper) record(seq *WrappedStringSeq) {
+	if seq != nil {
+		seq.CustomMeasurer = w.Metric == MetricWidth && w.Measurer != nil
+		seq.Metric = w.Metric
+		seq.WhiteSpace = w.WhiteSpace
+		seq.Reflow = w.Reflow
+		seq.CarriageReturn = w.CarriageReturn
+	}
+}
+
+// Tokenize splits the input string into tokens like the Tokenize
+// function, measuring widths with the Measurer or Metric of the wrapper.
+func (w Wrapper) Tokenize(str string) *TokenizedString {
+	return &TokenizedString{Tokens: tokenize(str, w.measurer())}
+}
+
+// WrapTokens wraps a pre-tokenized string using the configuration of the
+// wrapper, producing the same result as Wrap on the original string. If
+// the wrapper has a Measurer or Metric, the string must be tokenized
+// with the Tokenize method of the wrapper.
+func (w Wrapper) WrapTokens(t *TokenizedString) (string, *WrappedStringSeq, error) {
+	var wrapped string
+	var seq *WrappedStringSeq
+	var err error
+	if w.Reflow {
+		wrapped, seq, err = wrapReflowTokens(t.Tokens, w.config())
+	} else {
+		wrapped, seq, err = wrapTokensFrom(t.Tokens, w.config(), newPositions(1, 1, 0, 0))
+	}
+	if err != nil || w.MaxLines < 1 {
+		w.record(seq)
+		return wrapped, seq, err
+	}
+	wrapped, seq, err = w.truncate(t.text(), wrapped, seq)
+	w.record(seq)
+	return wrapped, seq, err
+}
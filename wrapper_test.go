@@ -0,0 +1,259 @@
+package stringwrap
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestWrapper_Wrap tests that the Wrapper produces the same result as
+// StringWrap and StringWrapSplit, both for strings and tokenized strings.
+func TestWrapper_Wrap(t *testing.T) {
+	input := "Supercalifragilisticexpialidocious is a long word\n\twith 🌟stars"
+
+	for _, splitWord := range []bool{false, true} {
+		t.Run(fmt.Sprintf("Wrapper Test %t", splitWord), func(t *testing.T) {
+			wrapper := Wrapper{Limit: 10, TabSize: 4, TrimWhitespace: true, SplitWord: splitWord}
+			expected, expectedSeq, _ := StringWrap(input, 10, 4, true)
+			if splitWord {
+				expected, expectedSeq, _ = StringWrapSplit(input, 10, 4, true)
+			}
+
+			wrapped, seq, err := wrapper.Wrap(input)
+			assert.Nil(t, err)
+			assert.Equal(t, expected, wrapped)
+			assert.Equal(t, expectedSeq, seq)
+
+			wrapped, seq, err = wrapper.WrapTokens(Tokenize(input))
+			assert.Nil(t, err)
+			assert.Equal(t, expected, wrapped)
+			assert.Equal(t, expectedSeq, seq)
+		})
+	}
+
+	_, _, err := Wrapper{Limit: 1}.Wrap(input)
+	assert.NotNil(t, err)
+}
+
+// markerTestCase is a struct that contains the input string, the wrapper
+// configuration, and the expected wrapped string.
+type markerTestCase struct {
+	input    string
+	wrapper  Wrapper
+	expected string
+}
+
+// TestWrapper_Markers tests wrapping with continuation markers with a
+// variety of test cases.
+func TestWrapper_Markers(t *testing.T) {
+	defer func(size int) { parallelChunkSize = size }(parallelChunkSize)
+	parallelChunkSize = 4
+
+	input := "The quick brown fox jumps over the lazy dog"
+	tests := []markerTestCase{
+		{
+			input:    input,
+			wrapper:  Wrapper{Limit: 12, TabSize: 4, TrimWhitespace: true, TrailingMarker: "↩"},
+			expected: "The quick↩\nbrown fox↩\njumps over↩\nthe lazy↩\ndog",
+		},
+		{
+			input:    input,
+			wrapper:  Wrapper{Limit: 12, TabSize: 4, TrimWhitespace: true, LeadingMarker: "↪ "},
+			expected: "The quick\n↪ brown fox\n↪ jumps over\n↪ the lazy\n↪ dog",
+		},
+		{
+			input: "echo one two three\necho four",
+			wrapper: Wrapper{
+				Limit: 12, TabSize: 4, TrimWhitespace: true, TrailingMarker: " \\",
+			},
+			expected: "echo one \\\ntwo three\necho four",
+		},
+		{
+			input: "Supercalifragilistic\nok",
+			wrapper: Wrapper{
+				Limit: 10, TabSize: 4, TrimWhitespace: true, SplitWord: true,
+				LeadingMarker: "\x1b[2m>\x1b[0m", TrailingMarker: "\x1b[2m↩\x1b[0m",
+			},
+			expected: "Supercal-\x1b[2m↩\x1b[0m\n\x1b[2m>\x1b[0mifragil-\x1b[2m↩\x1b[0m\n" +
+				"\x1b[2m>\x1b[0mistic\nok",
+		},
+		{
+			input:    "abcde   ",
+			wrapper:  Wrapper{Limit: 6, TabSize: 4, TrimWhitespace: true, TrailingMarker: "↩"},
+			expected: "abcde",
+		},
+		{
+			input: "short\nlines only",
+			wrapper: Wrapper{
+				Limit: 12, TabSize: 4, TrimWhitespace: true, LeadingMarker: "↪ ", TrailingMarker: "↩",
+			},
+			expected: "short\nlines only",
+		},
+	}
+
+	for idx, test := range tests {
+		t.Run(fmt.Sprintf("Marker Test %d", idx+1), func(t *testing.T) {
+			wrapped, seq, err := test.wrapper.Wrap(test.input)
+			assert.Nil(t, err)
+			assert.Equal(t, test.expected, wrapped)
+
+			// the width of each line includes its markers.
+			widths := make([]int, 0, len(seq.WrappedLines))
+			for _, wrappedLine := range seq.WrappedLines {
+				offset := wrappedLine.WrappedByteOffset
+				assert.Equal(t, Width(wrapped[offset.Start:offset.End]), wrappedLine.Width)
+				assert.LessOrEqual(t, wrappedLine.Width, test.wrapper.Limit)
+				widths = append(widths, wrappedLine.Width)
+			}
+
+			tokenized, tokenizedSeq, err := test.wrapper.WrapTokens(test.wrapper.Tokenize(test.input))
+			assert.Nil(t, err)
+			assert.Equal(t, wrapped, tokenized)
+			assert.Equal(t, seq, tokenizedSeq)
+
+			measurement, err := test.wrapper.Measure(test.input, true)
+			assert.Nil(t, err)
+			assert.Equal(t, widths, measurement.Widths)
+			assert.Equal(t, len(widths), measurement.Lines)
+
+			parallel := test.wrapper
+			parallel.Workers = 2
+			parallelWrapped, parallelSeq, err := parallel.Wrap(test.input)
+			assert.Nil(t, err)
+			assert.Equal(t, wrapped, parallelWrapped)
+			assert.Equal(t, seq, parallelSeq)
+		})
+	}
+
+	_, _, err := Wrapper{Limit: 4, TabSize: 4, LeadingMarker: "↪ ", TrailingMarker: "↩"}.Wrap(input)
+	assert.NotNil(t, err)
+}
+
+// TestWrapper_MarkersTruncate tests that truncated lines keep the
+// continuation markers of the lines they join.
+func TestWrapper_MarkersTruncate(t *testing.T) {
+	input := "The quick brown fox jumps over the lazy dog"
+	tests := []struct {
+		placement EllipsisPlacement
+		expected  string
+	}{
+		{placement: EllipsisEnd, expected: "The quick↩\n↪ brown fo…"},
+		{placement: EllipsisMiddle, expected: "The quick↩\n↪ brow…the↩\n↪ lazy dog"},
+		{placement: EllipsisStart, expected: "↪ …over the↩\n↪ lazy dog"},
+	}
+
+	for idx, test := range tests {
+		t.Run(fmt.Sprintf("Marker Truncate Test %d", idx+1), func(t *testing.T) {
+			wrapper := Wrapper{
+				Limit: 12, TabSize: 4, TrimWhitespace: true, LeadingMarker: "↪ ",
+				TrailingMarker: "↩", MaxLines: 2 + idx%2, Ellipsis: "…",
+				EllipsisPlacement: test.placement,
+			}
+			wrapped, seq, err := wrapper.Wrap(input)
+			assert.Nil(t, err)
+			assert.Equal(t, test.expected, wrapped)
+			for _, wrappedLine := range seq.WrappedLines {
+				offset := wrappedLine.WrappedByteOffset
+				assert.Equal(t, Width(wrapped[offset.Start:offset.End]), wrappedLine.Width)
+				assert.LessOrEqual(t, wrappedLine.Width, wrapper.Limit)
+			}
+		})
+	}
+}
+
+// tabStopTestCase is a struct that contains the input string, the
+// wrapper configuration, and the expected wrapped string and widths.
+type tabStopTestCase struct {
+	input    string
+	wrapper  Wrapper
+	expected string
+	widths   []int
+}
+
+// TestWrapper_TabStops tests expanding tabs to explicit tab stops, and
+// keeping tabs as they are, with a variety of test cases.
+func TestWrapper_TabStops(t *testing.T) {
+	tests := []tabStopTestCase{
+		{
+			input:    "a\tb\tc\td",
+			wrapper:  Wrapper{Limit: 40, TabSize: 4, TrimWhitespace: true, TabStops: []int{3, 8}},
+			expected: "a  b    c   d",
+			widths:   []int{13},
+		},
+		{
+			input: "a\tb\tc\td",
+			wrapper: Wrapper{
+				Limit: 40, TabSize: 4, TrimWhitespace: true, TabStops: []int{3, 8}, KeepTabs: true,
+			},
+			expected: "a\tb\tc\td",
+			widths:   []int{13},
+		},
+		{
+			input:    "word word\tmore",
+			wrapper:  Wrapper{Limit: 10, TabSize: 4, TrimWhitespace: true, KeepTabs: true},
+			expected: "word word\nmore",
+			widths:   []int{9, 4},
+		},
+		{
+			input:    "\tab cd\n\tef",
+			wrapper:  Wrapper{Limit: 10, TabSize: 4, TrimWhitespace: false, KeepTabs: true},
+			expected: "\tab cd\n\tef",
+			widths:   []int{9, 6},
+		},
+		{
+			input:    "\tab\t\ncd",
+			wrapper:  Wrapper{Limit: 10, TabSize: 4, TrimWhitespace: true, KeepTabs: true},
+			expected: "ab\ncd",
+			widths:   []int{2, 2},
+		},
+		{
+			input: "ab\tcd\tef gh",
+			wrapper: Wrapper{
+				Limit: 10, TabSize: 0, TrimWhitespace: true, TabStops: []int{6}, KeepTabs: true,
+			},
+			expected: "ab\tcdef\ngh",
+			widths:   []int{10, 2},
+		},
+		{
+			input: "x\ty",
+			wrapper: Wrapper{
+				Limit: 10, TabSize: 4, TrimWhitespace: true, KeepTabs: true,
+				CarriageReturn: CarriageReturnOverwrite,
+			},
+			expected: "x   y",
+			widths:   []int{5},
+		},
+	}
+
+	for idx, test := range tests {
+		t.Run(fmt.Sprintf("Tab Stop Test %d", idx+1), func(t *testing.T) {
+			wrapped, seq, err := test.wrapper.Wrap(test.input)
+			assert.Nil(t, err)
+			assert.Equal(t, test.expected, wrapped)
+
+			var widths []int
+			for _, wrappedLine := range seq.WrappedLines {
+				widths = append(widths, wrappedLine.Width)
+			}
+			assert.Equal(t, test.widths, widths)
+
+			measurement, err := test.wrapper.Measure(test.input, true)
+			assert.Nil(t, err)
+			assert.Equal(t, test.widths, measurement.Widths)
+
+			// rewrapping after an edit keeps the tab configuration.
+			edit := Edit{Start: 0, End: 0, Text: "\t"}
+			rewrapped, rewrappedSeq, err := RewrapEdit(test.input, wrapped, seq, edit)
+			assert.Nil(t, err)
+			expected, expectedSeq, _ := test.wrapper.Wrap(edit.Apply(test.input))
+			assert.Equal(t, expected, rewrapped)
+			assert.Equal(t, expectedSeq, rewrappedSeq)
+		})
+	}
+
+	for _, stops := range [][]int{{0, 4}, {4, 4}, {8, 4}} {
+		_, _, err := Wrapper{Limit: 10, TabSize: 4, TabStops: stops}.Wrap("a\tb")
+		assert.NotNil(t, err)
+	}
+}